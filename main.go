@@ -12,28 +12,45 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"net/http"
 	"os"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/activity"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/certs"
 	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/credentials"
 	"github.com/redhat-developer/web-terminal-exec/pkg/handler"
+	"github.com/redhat-developer/web-terminal-exec/pkg/injector"
+	"github.com/redhat-developer/web-terminal-exec/pkg/lifecycle"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/session"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	TLSCertFile = "/var/serving-cert/tls.crt"
-	TLSKeyFile  = "/var/serving-cert/tls.key"
-)
-
 func main() {
 	if err := config.ParseConfig(); err != nil {
 		logrus.Error(err)
 		os.Exit(1)
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		logrus.Errorf("Unable to set up tracing: %s", err)
+		os.Exit(1)
+	}
+
+	certWatcher, err := certs.NewWatcher(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		logrus.Errorf("Unable to load TLS certificate: %s", err)
+		os.Exit(1)
+	}
+	certWatcher.Start(constants.CertificatePollPeriod)
+
 	clientProvider := operations.DefaultClientProvider()
 
 	activityManager, err := activity.NewActivityManager(config.IdleTimeout, config.StopRetryPeriod, clientProvider)
@@ -41,11 +58,55 @@ func main() {
 		logrus.Errorf("Unable to create activity manager: %s", err)
 		os.Exit(1)
 	}
+
+	authenticator, err := auth.NewAuthenticator(config.AuthStrategies, clientProvider)
+	if err != nil {
+		logrus.Errorf("Unable to create authenticator: %s", err)
+		os.Exit(1)
+	}
+	authorizer, err := auth.NewAuthorizer(config.AuthorizationMode, clientProvider)
+	if err != nil {
+		logrus.Errorf("Unable to create authorizer: %s", err)
+		os.Exit(1)
+	}
+
+	var credentialStore *credentials.Store
+	if config.CredentialMode == config.CredentialModeExecPlugin {
+		credentialStore, err = credentials.NewStore(config.CredentialPluginURL)
+		if err != nil {
+			logrus.Errorf("Unable to create credential store: %s", err)
+			os.Exit(1)
+		}
+	}
+
+	credentialInjectors, err := injector.New(config.CredentialInjectors, config.RegistryAuthHost)
+	if err != nil {
+		logrus.Errorf("Unable to create credential injectors: %s", err)
+		os.Exit(1)
+	}
+
+	// Injected credentials are removed before the workspace is stopped by inactivity, and when the server shuts down
+	credentialTracker := injector.NewTracker()
+	removeCredentials := func(ctx context.Context) error {
+		if credentialStore != nil {
+			credentialStore.ClearToken()
+		}
+		return credentialTracker.Cleanup(ctx, clientProvider)
+	}
+	activityManager.BeforeStop(removeCredentials)
 	activityManager.Start()
 
+	sessionRegistry := session.NewRegistry(config.ScrollbackBytes, config.RecordingDir)
 	router := handler.Router{
-		ActivityManager: activityManager,
-		ClientProvider:  clientProvider,
+		ActivityManager:     activityManager,
+		ClientProvider:      clientProvider,
+		SessionRegistry:     sessionRegistry,
+		Authenticator:       authenticator,
+		Authorizer:          authorizer,
+		CredentialStore:     credentialStore,
+		CredentialInjectors: credentialInjectors,
+		CredentialTracker:   credentialTracker,
+		UserCache:           auth.NewUserCache(config.AuthCacheTTL, config.AuthCacheNegativeTTL, config.AuthCacheSize),
 	}
 
 	server := http.Server{
@@ -54,8 +115,55 @@ func main() {
 		ReadTimeout:    constants.ServerReadTimeout,
 		WriteTimeout:   constants.ServerWriteTimeout,
 		MaxHeaderBytes: constants.MaxHeaderBytes,
+		TLSConfig: &tls.Config{
+			MinVersion:     config.TLSMinVersion,
+			CipherSuites:   config.TLSCipherSuites,
+			GetCertificate: certWatcher.GetCertificate,
+		},
 	}
-	if err := server.ListenAndServeTLS(TLSCertFile, TLSKeyFile); err != nil {
-		logrus.Errorf("Failed to start server with TLS enabled: %s", err)
+
+	// The credential plugin server is only reachable from within the workspace pod, so it does not use TLS
+	var credentialServer *http.Server
+	if credentialStore != nil {
+		credentialServer = &http.Server{
+			Addr:           config.CredentialPluginURL,
+			Handler:        router.CredentialPluginHandler(),
+			ReadTimeout:    constants.ServerReadTimeout,
+			WriteTimeout:   constants.ServerWriteTimeout,
+			MaxHeaderBytes: constants.MaxHeaderBytes,
+		}
 	}
+
+	lifecycleManager := lifecycle.NewManager(&server, constants.ShutdownGracePeriod, constants.ShutdownHookTimeout)
+	// Credentials are removed first, so that they are removed before the pod is killed even if other components
+	// are slow to shut down
+	lifecycleManager.OnShutdown("injected credentials", removeCredentials)
+	if credentialServer != nil {
+		lifecycleManager.OnShutdown("credential plugin server", credentialServer.Shutdown)
+	}
+	lifecycleManager.OnShutdown("terminal sessions", sessionRegistry.Shutdown)
+	lifecycleManager.OnShutdown("activity manager", func(context.Context) error {
+		activityManager.Stop()
+		return nil
+	})
+	lifecycleManager.OnShutdown("certificate watcher", func(context.Context) error {
+		certWatcher.Stop()
+		return nil
+	})
+	// Registered last so that spans recorded during shutdown are exported
+	lifecycleManager.OnShutdown("tracing", shutdownTracing)
+
+	os.Exit(lifecycleManager.Run(context.Background(), func() error {
+		serveErr := make(chan error, 2)
+		if credentialServer != nil {
+			go func() {
+				serveErr <- credentialServer.ListenAndServe()
+			}()
+		}
+		go func() {
+			// Certificates are provided by TLSConfig.GetCertificate
+			serveErr <- server.ListenAndServeTLS("", "")
+		}()
+		return <-serveErr
+	}))
 }
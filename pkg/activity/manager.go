@@ -11,12 +11,12 @@
 package activity
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/dynamic"
@@ -29,31 +29,54 @@ type ActivityManager interface {
 
 	// Tick registers users activity and postpones workspace stopping by inactivity
 	Tick()
+
+	// Stop stops tracking users activity; the workspace will no longer be stopped by inactivity
+	Stop()
+
+	// BeforeStop registers fn to be called once before the workspace is stopped by inactivity, e.g. to clean up
+	// the workspace. The workspace is stopped even if fn fails or does not finish within
+	// constants.WorkspaceCleanupTimeout. Should be called before Start
+	BeforeStop(fn func(ctx context.Context) error)
 }
 
 type noOpManager struct{}
 
-func (*noOpManager) Tick()  {}
-func (*noOpManager) Start() {}
+func (*noOpManager) Tick()                                  {}
+func (*noOpManager) Start()                                 {}
+func (*noOpManager) Stop()                                  {}
+func (*noOpManager) BeforeStop(func(context.Context) error) {}
 
 type activityManager struct {
 	idleTimeout        time.Duration
 	stopRetryPeriod    time.Duration
 	devworkspaceClient dynamic.Interface
 	activityC          chan bool
+	// beforeStop are called before the first attempt to stop the workspace after activity
+	beforeStop []func(ctx context.Context) error
+	// cleanupTimeout bounds the time taken by all beforeStop functions together
+	cleanupTimeout time.Duration
+	// ctx is cancelled when the activity manager is stopped, aborting any in-progress attempt to stop the
+	// workspace
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func (m *activityManager) Start() {
 	logrus.Infof("DevWorkspace will be stopped automatically in %s if there is no activity", m.idleTimeout)
 	timer := time.NewTimer(m.idleTimeout)
-	var shutdownChan = make(chan os.Signal, 1)
-	signal.Notify(shutdownChan, syscall.SIGTERM)
 
 	go func() {
+		stopping := false
 		for {
 			select {
 			case <-timer.C:
-				if err := operations.StopDevWorkspace(m.devworkspaceClient); err != nil {
+				if !stopping {
+					stopping = true
+					m.prepareStop()
+				}
+				err := operations.StopDevWorkspace(m.ctx, m.devworkspaceClient)
+				metrics.WorkspaceStopAttempted(err)
+				if err != nil {
 					timer.Reset(m.stopRetryPeriod)
 					logrus.Errorf("Failed to stop workspace. Will retry in %s. Cause: %s", m.stopRetryPeriod, err)
 				} else {
@@ -62,18 +85,35 @@ func (m *activityManager) Start() {
 				}
 			case <-m.activityC:
 				logrus.Debug("Activity is reported. Resetting timer")
+				metrics.IdleTimerReset()
+				// The workspace may have been used since an attempt to stop it failed (e.g. credentials may have
+				// been injected again), so it is prepared for stopping again before the next attempt
+				stopping = false
 				if !timer.Stop() {
 					<-timer.C
 				}
 				timer.Reset(m.idleTimeout)
-			case <-shutdownChan:
-				logrus.Info("Received SIGTERM: shutting down activity manager")
+			case <-m.ctx.Done():
+				logrus.Info("Shutting down activity manager")
+				timer.Stop()
 				return
 			}
 		}
 	}()
 }
 
+// prepareStop calls the beforeStop functions, which are given at most cleanupTimeout to finish so that a
+// cleanup that hangs does not prevent the workspace from being stopped.
+func (m *activityManager) prepareStop() {
+	ctx, cancel := context.WithTimeout(m.ctx, m.cleanupTimeout)
+	defer cancel()
+	for _, fn := range m.beforeStop {
+		if err := fn(ctx); err != nil {
+			logrus.Errorf("Failed to prepare workspace for stopping: %s", err)
+		}
+	}
+}
+
 func (m *activityManager) Tick() {
 	select {
 	case m.activityC <- true:
@@ -83,6 +123,14 @@ func (m *activityManager) Tick() {
 	}
 }
 
+func (m *activityManager) Stop() {
+	m.cancel()
+}
+
+func (m *activityManager) BeforeStop(fn func(ctx context.Context) error) {
+	m.beforeStop = append(m.beforeStop, fn)
+}
+
 func NewActivityManager(idleTimeout, stopRetryPeriod time.Duration, clientProvider operations.ClientProvider) (ActivityManager, error) {
 	if idleTimeout < 0 {
 		return &noOpManager{}, nil
@@ -92,15 +140,19 @@ func NewActivityManager(idleTimeout, stopRetryPeriod time.Duration, clientProvid
 		return nil, fmt.Errorf("stop retry period must be greater than 0 if idling is enabled")
 	}
 
-	devworkspaceClient, _, err := clientProvider.NewDevWorkspaceClient()
+	devworkspaceClient, _, err := clientProvider.NewDevWorkspaceClient(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes API client: %s", err)
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	activityManager := &activityManager{
 		idleTimeout:        idleTimeout,
 		stopRetryPeriod:    stopRetryPeriod,
+		cleanupTimeout:     constants.WorkspaceCleanupTimeout,
 		devworkspaceClient: devworkspaceClient,
 		activityC:          make(chan bool),
+		ctx:                ctx,
+		cancel:             cancel,
 	}
 	return activityManager, nil
 }
@@ -12,6 +12,7 @@ package activity
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/yaml"
 )
 
@@ -58,6 +60,107 @@ func TestActivityManagerTimesOut(t *testing.T) {
 	assert.False(t, workspaceIsStarted(t, newWorkspace), "Workspace should be stopped")
 }
 
+func TestActivityManagerCallsBeforeStop(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	workspace := loadDevWorkspaceFromFile(t)
+	config.DevWorkspaceName = workspace.GetName()
+	config.DevWorkspaceNamespace = workspace.GetNamespace()
+	config.DevWorkspaceID = "test-id"
+	defer config.ResetConfigForTest()
+
+	fakeClientProvider := test.FakeClientProvider{InitialDynamic: []runtime.Object{&workspace}}
+	manager, err := NewActivityManager(1*time.Millisecond, 1*time.Millisecond, fakeClientProvider)
+	assert.NoError(t, err)
+	client := manager.(*activityManager).devworkspaceClient
+	calls := make(chan bool, 10)
+	manager.BeforeStop(func(ctx context.Context) error {
+		workspace, err := client.Resource(testDevworkspaceGVR).Namespace(config.DevWorkspaceNamespace).Get(ctx, config.DevWorkspaceName, metav1.GetOptions{})
+		if assert.NoError(t, err) {
+			calls <- workspaceIsStarted(t, workspace)
+		}
+		return fmt.Errorf("test error")
+	})
+	manager.Start()
+	time.Sleep(20 * time.Millisecond)
+	newWorkspace, err := client.Resource(testDevworkspaceGVR).Namespace(workspace.GetNamespace()).Get(context.TODO(), workspace.GetName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.False(t, workspaceIsStarted(t, newWorkspace), "Workspace should be stopped even if BeforeStop fails")
+	if assert.Len(t, calls, 1, "Should call BeforeStop once") {
+		assert.True(t, <-calls, "Should call BeforeStop before stopping workspace")
+	}
+}
+
+func TestActivityManagerBoundsBeforeStop(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	workspace := loadDevWorkspaceFromFile(t)
+	config.DevWorkspaceName = workspace.GetName()
+	config.DevWorkspaceNamespace = workspace.GetNamespace()
+	config.DevWorkspaceID = "test-id"
+	defer config.ResetConfigForTest()
+
+	fakeClientProvider := test.FakeClientProvider{InitialDynamic: []runtime.Object{&workspace}}
+	manager, err := NewActivityManager(1*time.Millisecond, 1*time.Millisecond, fakeClientProvider)
+	assert.NoError(t, err)
+	defer manager.Stop()
+	client := manager.(*activityManager).devworkspaceClient
+	manager.(*activityManager).cleanupTimeout = 5 * time.Millisecond
+	manager.BeforeStop(func(ctx context.Context) error {
+		// Simulate a cleanup that hangs until its context expires
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	manager.Start()
+	time.Sleep(50 * time.Millisecond)
+	newWorkspace, err := client.Resource(testDevworkspaceGVR).Namespace(workspace.GetNamespace()).Get(context.TODO(), workspace.GetName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.False(t, workspaceIsStarted(t, newWorkspace), "Workspace should be stopped if BeforeStop does not finish in time")
+}
+
+func TestActivityManagerCallsBeforeStopAgainAfterActivity(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	workspace := loadDevWorkspaceFromFile(t)
+	config.DevWorkspaceName = workspace.GetName()
+	config.DevWorkspaceNamespace = workspace.GetNamespace()
+	config.DevWorkspaceID = "test-id"
+	defer config.ResetConfigForTest()
+
+	fakeDynamicClient := fake.NewSimpleDynamicClient(&runtime.Scheme{}, &workspace)
+	patches := 0
+	fakeDynamicClient.PrependReactor("patch", "devworkspaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		patches++
+		if patches == 1 {
+			return true, nil, fmt.Errorf("test error")
+		}
+		return false, nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager := activityManager{
+		idleTimeout: 10 * time.Millisecond,
+		// Do not retry, so that the workspace is only stopped once it becomes idle again
+		stopRetryPeriod:    time.Hour,
+		cleanupTimeout:     time.Second,
+		devworkspaceClient: fakeDynamicClient,
+		activityC:          make(chan bool),
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+	calls := make(chan bool, 10)
+	manager.BeforeStop(func(context.Context) error {
+		calls <- true
+		return nil
+	})
+	manager.Start()
+	assert.Eventually(t, func() bool { return len(calls) == 1 }, time.Second, time.Millisecond, "Should call BeforeStop before first attempt")
+	manager.activityC <- true
+	assert.Eventually(t, func() bool {
+		newWorkspace, err := fakeDynamicClient.Resource(testDevworkspaceGVR).Namespace(workspace.GetNamespace()).Get(context.TODO(), workspace.GetName(), metav1.GetOptions{})
+		return err == nil && !workspaceIsStarted(t, newWorkspace)
+	}, time.Second, time.Millisecond, "Workspace should be stopped once idle again")
+	assert.Len(t, calls, 2, "Should call BeforeStop again after activity following a failed stop")
+}
+
 func TestTickResetsTimer(t *testing.T) {
 	logrus.SetOutput(io.Discard)
 	workspace := loadDevWorkspaceFromFile(t)
@@ -68,11 +171,15 @@ func TestTickResetsTimer(t *testing.T) {
 
 	fakeDynamicClient := fake.NewSimpleDynamicClient(&runtime.Scheme{}, &workspace)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	manager := activityManager{
 		idleTimeout:        5 * time.Millisecond,
 		stopRetryPeriod:    5 * time.Millisecond,
 		devworkspaceClient: fakeDynamicClient,
 		activityC:          make(chan bool),
+		ctx:                ctx,
+		cancel:             cancel,
 	}
 	activity, done := time.NewTicker(1*time.Millisecond), make(chan bool)
 	go func() {
@@ -94,6 +201,27 @@ func TestTickResetsTimer(t *testing.T) {
 	close(done)
 }
 
+func TestStopPreventsTimeout(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	workspace := loadDevWorkspaceFromFile(t)
+	config.DevWorkspaceName = workspace.GetName()
+	config.DevWorkspaceNamespace = workspace.GetNamespace()
+	config.DevWorkspaceID = "test-id"
+	defer config.ResetConfigForTest()
+
+	fakeClientProvider := test.FakeClientProvider{InitialDynamic: []runtime.Object{&workspace}}
+	manager, err := NewActivityManager(10*time.Millisecond, 10*time.Millisecond, fakeClientProvider)
+	assert.NoError(t, err)
+	manager.Start()
+	manager.Stop()
+	manager.Stop() // Stop should be safe to call more than once
+	time.Sleep(30 * time.Millisecond)
+	client := manager.(*activityManager).devworkspaceClient
+	newWorkspace, err := client.Resource(testDevworkspaceGVR).Namespace(workspace.GetNamespace()).Get(context.TODO(), workspace.GetName(), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, workspaceIsStarted(t, newWorkspace), "Workspace should not be stopped after activity manager is stopped")
+}
+
 func TestActivityManagerIsNoOpIfNoIdleTimeout(t *testing.T) {
 	manager, err := NewActivityManager(-1, 0, nil)
 	assert.NoError(t, err)
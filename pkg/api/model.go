@@ -10,6 +10,8 @@
 
 package api
 
+import "time"
+
 type InitParams struct {
 	ContainerName    string `json:"container"` // optional, Will be first suitable container in pod if not set
 	KubeConfigParams `json:"kubeconfig"`
@@ -19,10 +21,74 @@ type KubeConfigParams struct {
 	Namespace   string `json:"namespace"`   //optional, Is not set into kubeconfig file if is not set or empty
 	Username    string `json:"username"`    //optional, Developer in kubeconfig if empty
 	BearerToken string `json:"bearertoken"` //evaluated from header
+	Merge       bool   `json:"merge"`       //optional, Merge into existing kubeconfig instead of overwriting it if true
+
+	Namespaces         []string `json:"namespaces"`         //optional, Namespaces for which additional contexts are added to kubeconfig
+	DiscoverNamespaces bool     `json:"discoverNamespaces"` //optional, Add contexts for all namespaces the user can access if true
+
+	Server                   string `json:"server"`                   //optional, API server URL in kubeconfig; in-cluster address if empty
+	CertificateAuthorityData string `json:"certificateAuthorityData"` //optional, base64-encoded PEM CA bundle; service account CA bundle if empty
+	TLSServerName            string `json:"tlsServerName"`            //optional, server name used to verify the API server's certificate
+	ProxyURL                 string `json:"proxyURL"`                 //optional, proxy used for requests to the API server
 }
 
 type ExecInitResponse struct {
-	PodName       string   `json:"pod"`
-	ContainerName string   `json:"container"`
-	Cmd           []string `json:"cmd"`
+	PodName       string               `json:"pod"`
+	ContainerName string               `json:"container"`
+	Cmd           []string             `json:"cmd"`
+	TokenExpiry   *time.Time           `json:"tokenExpiry,omitempty"` // Expiry of the token in the kubeconfig, if known
+	Credentials   []InjectedCredential `json:"credentials,omitempty"` // Credential files written to the container
+}
+
+// InjectedCredential is a file containing the user's credentials written to the container by /exec/init.
+type InjectedCredential struct {
+	Injector string `json:"injector"` // Name of the injector that wrote the file, e.g. "kubeconfig"
+	Path     string `json:"path"`     // Path of the file in the container
+}
+
+type ExecRefreshResponse struct {
+	PodName       string               `json:"pod"`
+	ContainerName string               `json:"container"`
+	TokenExpiry   *time.Time           `json:"tokenExpiry,omitempty"` // Expiry of the token in the kubeconfig, if known
+	Credentials   []InjectedCredential `json:"credentials,omitempty"` // Credential files in which the token was replaced
+}
+
+type ExecLogoutResponse struct {
+	Credentials []InjectedCredential `json:"credentials"` // Credential files from which the user's credentials were removed
+}
+
+const (
+	TerminalMessageInput   = "input"
+	TerminalMessageResize  = "resize"
+	TerminalMessageSession = "session"
+)
+
+// TerminalMessage is sent as a text message over the /exec/connect WebSocket. Clients send "input" and
+// "resize" messages; the server sends a "session" message once the terminal is attached to a session.
+// Terminal output is sent to the client as binary messages.
+type TerminalMessage struct {
+	Type      string `json:"type"`              // One of "input", "resize" or "session"
+	Data      string `json:"data,omitempty"`    // Terminal input, for "input" messages
+	Cols      uint16 `json:"cols,omitempty"`    // Terminal width, for "resize" messages
+	Rows      uint16 `json:"rows,omitempty"`    // Terminal height, for "resize" messages
+	SessionID string `json:"session,omitempty"` // ID of the attached session, for "session" messages
+}
+
+type SessionInfo struct {
+	ID            string    `json:"id"`
+	PodName       string    `json:"pod"`
+	ContainerName string    `json:"container"`
+	Shell         string    `json:"shell"`
+	StartTime     time.Time `json:"startTime"`
+	LastActivity  time.Time `json:"lastActivity"`
+	Attached      bool      `json:"attached"` // Whether a client is currently connected to the session
+	Recorded      bool      `json:"recorded"` // Whether the session is being recorded
+}
+
+// ErrorResponse is the body of all error responses returned by the server.
+type ErrorResponse struct {
+	Code      string `json:"code"`                // Stable, machine-readable error code
+	Message   string `json:"message"`             // Human-readable description of the error
+	Details   string `json:"details,omitempty"`   // Additional information about the cause of the error
+	RequestID string `json:"requestId,omitempty"` // ID of the request, for correlation with server logs
 }
@@ -0,0 +1,198 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AccessLevel is the level of access a user has to the terminal. Higher levels include lower ones.
+type AccessLevel int
+
+const (
+	// AccessNone denies access to all endpoints that require authentication
+	AccessNone AccessLevel = iota
+	// AccessReadOnly allows access to endpoints that do not start, modify or interact with terminal sessions
+	AccessReadOnly
+	// AccessFull allows access to all endpoints
+	AccessFull
+)
+
+func (l AccessLevel) String() string {
+	switch l {
+	case AccessReadOnly:
+		return "read-only"
+	case AccessFull:
+		return "full"
+	default:
+		return "none"
+	}
+}
+
+// Authorizer determines the level of access an authenticated user has to the terminal.
+type Authorizer interface {
+	// Authorize returns the access level of user, or an error if it cannot be determined
+	Authorize(ctx context.Context, user *UserInfo) (AccessLevel, error)
+}
+
+// NewAuthorizer returns the Authorizer for mode, which is one of the values supported by
+// config.AuthorizationMode.
+func NewAuthorizer(mode string, clientProvider operations.ClientProvider) (Authorizer, error) {
+	switch mode {
+	case config.AuthorizationModeAllowList:
+		return allowListAuthorizer{}, nil
+	case config.AuthorizationModeSubjectAccessReview:
+		return &subjectAccessReviewAuthorizer{clientProvider}, nil
+	default:
+		return nil, fmt.Errorf("unknown authorization mode '%s'", mode)
+	}
+}
+
+// DefaultAuthorizer returns an Authorizer that uses config.AuthenticatedUserID and config.AuthorizedPrincipals.
+func DefaultAuthorizer() Authorizer {
+	return allowListAuthorizer{}
+}
+
+// allowListAuthorizer grants full access to the user identified by config.AuthenticatedUserID; other users are
+// granted the highest access level of the principals in config.AuthorizedPrincipals that match their UID,
+// username or groups.
+type allowListAuthorizer struct{}
+
+func (allowListAuthorizer) Authorize(_ context.Context, user *UserInfo) (AccessLevel, error) {
+	if user.UID == config.AuthenticatedUserID {
+		return AccessFull, nil
+	}
+	access := AccessNone
+	for _, principal := range config.AuthorizedPrincipals {
+		if !principalMatches(principal, user) {
+			continue
+		}
+		if !principal.ReadOnly {
+			return AccessFull, nil
+		}
+		access = AccessReadOnly
+	}
+	return access, nil
+}
+
+func principalMatches(principal config.Principal, user *UserInfo) bool {
+	switch principal.Kind {
+	case config.PrincipalKindUID:
+		return user.UID != "" && principal.Name == user.UID
+	case config.PrincipalKindUser:
+		return user.Username != "" && principal.Name == user.Username
+	case config.PrincipalKindGroup:
+		return slices.Contains(user.Groups, principal.Name)
+	default:
+		return false
+	}
+}
+
+// subjectAccessReviewAuthorizer grants full access to users that cluster RBAC allows to create pods/exec on the
+// workspace pod or to patch the current DevWorkspace. Access is checked with a SubjectAccessReview using the
+// server's service account, which must be allowed to create subjectaccessreviews (e.g. via the
+// system:auth-delegator cluster role) and to list pods in the workspace namespace.
+type subjectAccessReviewAuthorizer struct {
+	clientProvider operations.ClientProvider
+}
+
+// fullAccessAttributes returns the attributes checked by subjectAccessReviewAuthorizer for the workspace pod
+// podName. Users allowed any of them have full access.
+func fullAccessAttributes(podName string) []authorizationv1.ResourceAttributes {
+	return []authorizationv1.ResourceAttributes{
+		{
+			Namespace:   config.DevWorkspaceNamespace,
+			Verb:        "create",
+			Resource:    "pods",
+			Subresource: "exec",
+			Name:        podName,
+		},
+		{
+			Namespace: config.DevWorkspaceNamespace,
+			Verb:      "patch",
+			Group:     "workspace.devfile.io",
+			Resource:  "devworkspaces",
+			Name:      config.DevWorkspaceName,
+		},
+	}
+}
+
+func (a *subjectAccessReviewAuthorizer) Authorize(ctx context.Context, user *UserInfo) (AccessLevel, error) {
+	client, _, err := a.clientProvider.NewServiceAccountClient(ctx)
+	if err != nil {
+		return AccessNone, fmt.Errorf("failed to create client to review access: %w", err)
+	}
+	workspacePod, err := operations.GetCurrentWorkspacePod(ctx, client)
+	if err != nil {
+		return AccessNone, fmt.Errorf("failed to get workspace pod to review access: %w", err)
+	}
+	for _, attributes := range fullAccessAttributes(workspacePod.Name) {
+		allowed, err := reviewAccess(ctx, client, user, attributes)
+		if err != nil {
+			return AccessNone, err
+		}
+		if allowed {
+			return AccessFull, nil
+		}
+	}
+	return AccessNone, nil
+}
+
+func reviewAccess(ctx context.Context, client kubernetes.Interface, user *UserInfo, attributes authorizationv1.ResourceAttributes) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.AccessReviewTimeout)
+	defer cancel()
+	// Extra must be included so that the review is restricted by the scopes of the user's token
+	var extra map[string]authorizationv1.ExtraValue
+	if len(user.Extra) > 0 {
+		extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for key, value := range user.Extra {
+			extra[key] = authorizationv1.ExtraValue(value)
+		}
+	}
+	review, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to review access to %s %s: %w", attributes.Verb, resourceName(attributes), err)
+	}
+	if review.Status.EvaluationError != "" {
+		logging.FromContext(ctx).Debugf("Error evaluating access to %s %s: %s", attributes.Verb, resourceName(attributes), review.Status.EvaluationError)
+	}
+	return review.Status.Allowed, nil
+}
+
+// resourceName formats attributes as e.g. pods/exec, for use in errors
+func resourceName(attributes authorizationv1.ResourceAttributes) string {
+	name := attributes.Resource
+	if attributes.Group != "" {
+		name += "." + attributes.Group
+	}
+	if attributes.Subresource != "" {
+		name += "/" + attributes.Subresource
+	}
+	return name
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations/test"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *UserInfo
+		principals []config.Principal
+		expected   AccessLevel
+	}{
+		{
+			name:     "Workspace owner has full access",
+			user:     &UserInfo{UID: testToken},
+			expected: AccessFull,
+		},
+		{
+			name:     "Unknown user has no access",
+			user:     &UserInfo{UID: "other-uid", Username: "other", Groups: []string{"other-group"}},
+			expected: AccessNone,
+		},
+		{
+			name:       "Matches UID",
+			user:       &UserInfo{UID: "other-uid"},
+			principals: []config.Principal{{Kind: config.PrincipalKindUID, Name: "other-uid"}},
+			expected:   AccessFull,
+		},
+		{
+			name:       "Matches username",
+			user:       &UserInfo{UID: "other-uid", Username: "alice"},
+			principals: []config.Principal{{Kind: config.PrincipalKindUser, Name: "alice", ReadOnly: true}},
+			expected:   AccessReadOnly,
+		},
+		{
+			name:       "Matches group",
+			user:       &UserInfo{UID: "other-uid", Groups: []string{"viewers", "admins"}},
+			principals: []config.Principal{{Kind: config.PrincipalKindGroup, Name: "admins"}},
+			expected:   AccessFull,
+		},
+		{
+			name: "Highest access level wins",
+			user: &UserInfo{UID: "other-uid", Username: "alice", Groups: []string{"admins"}},
+			principals: []config.Principal{
+				{Kind: config.PrincipalKindUser, Name: "alice", ReadOnly: true},
+				{Kind: config.PrincipalKindGroup, Name: "admins"},
+			},
+			expected: AccessFull,
+		},
+		{
+			name:       "Does not match UID principal against username",
+			user:       &UserInfo{UID: "other-uid", Username: "alice"},
+			principals: []config.Principal{{Kind: config.PrincipalKindUID, Name: "alice"}},
+			expected:   AccessNone,
+		},
+		{
+			name:       "Does not match empty username",
+			user:       &UserInfo{UID: "other-uid"},
+			principals: []config.Principal{{Kind: config.PrincipalKindUser, Name: ""}},
+			expected:   AccessNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AuthenticatedUserID = testToken
+			config.AuthorizedPrincipals = tt.principals
+			defer config.ResetConfigForTest()
+			access, err := DefaultAuthorizer().Authorize(context.Background(), tt.user)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, access)
+		})
+	}
+}
+
+func TestSubjectAccessReviewAuthorizer(t *testing.T) {
+	user := &UserInfo{
+		UID:      "test-uid",
+		Username: "alice",
+		Groups:   []string{"developers"},
+		Extra:    map[string][]string{"scopes.authorization.openshift.io": {"user:info"}},
+	}
+	tests := []struct {
+		name      string
+		allowed   string
+		reviewErr error
+		noPod     bool
+		expected  AccessLevel
+		errRegexp string
+	}{
+		{
+			name:     "Allowed to exec into pods",
+			allowed:  "pods/exec",
+			expected: AccessFull,
+		},
+		{
+			name:     "Allowed to patch DevWorkspace",
+			allowed:  "devworkspaces",
+			expected: AccessFull,
+		},
+		{
+			name:     "Not allowed",
+			expected: AccessNone,
+		},
+		{
+			name:      "SubjectAccessReview fails",
+			reviewErr: apierrors.NewForbidden(schema.GroupResource{Group: "authorization.k8s.io", Resource: "subjectaccessreviews"}, "", fmt.Errorf("test")),
+			expected:  AccessNone,
+			errRegexp: "failed to review access to create pods/exec",
+		},
+		{
+			name:      "Workspace pod not found",
+			allowed:   "devworkspaces",
+			noPod:     true,
+			expected:  AccessNone,
+			errRegexp: "failed to get workspace pod to review access",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.DevWorkspaceName = "test-workspace"
+			config.DevWorkspaceNamespace = "test-namespace"
+			defer config.ResetConfigForTest()
+
+			var reviews []authorizationv1.SubjectAccessReviewSpec
+			var pods []runtime.Object
+			if !tt.noPod {
+				pods = append(pods, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				})
+			}
+			provider := accessReviewClientProvider{
+				pods: pods,
+				review: func(spec authorizationv1.SubjectAccessReviewSpec) (bool, error) {
+					reviews = append(reviews, spec)
+					attributes := spec.ResourceAttributes
+					return attributes.Resource+"/"+attributes.Subresource == tt.allowed || attributes.Resource == tt.allowed, tt.reviewErr
+				},
+			}
+			authorizer, err := NewAuthorizer(config.AuthorizationModeSubjectAccessReview, provider)
+			if !assert.NoError(t, err) {
+				return
+			}
+			access, err := authorizer.Authorize(context.Background(), user)
+			if tt.errRegexp != "" {
+				assert.Error(t, err)
+				assert.Regexp(t, tt.errRegexp, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, access)
+			if tt.noPod {
+				assert.Empty(t, reviews, "Should not review access if workspace pod is not found")
+				return
+			}
+			if assert.NotEmpty(t, reviews) {
+				assert.Equal(t, "alice", reviews[0].User)
+				assert.Equal(t, "test-uid", reviews[0].UID)
+				assert.Equal(t, []string{"developers"}, reviews[0].Groups)
+				assert.Equal(t, map[string]authorizationv1.ExtraValue{"scopes.authorization.openshift.io": {"user:info"}}, reviews[0].Extra, "Should restrict review to token scopes")
+				assert.Equal(t, "test-namespace", reviews[0].ResourceAttributes.Namespace)
+				assert.Equal(t, "test-pod", reviews[0].ResourceAttributes.Name, "Should review access to workspace pod")
+			}
+		})
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	_, err := NewAuthorizer(config.AuthorizationModeAllowList, test.NoOpClientProvider{})
+	assert.NoError(t, err)
+	_, err = NewAuthorizer("unknown", test.NoOpClientProvider{})
+	assert.Error(t, err)
+	assert.Regexp(t, "unknown authorization mode 'unknown'", err.Error())
+}
+
+// accessReviewClientProvider returns a service account client that serves pods and responds to SubjectAccessReviews
+// using review
+type accessReviewClientProvider struct {
+	test.NoOpClientProvider
+	pods   []runtime.Object
+	review func(authorizationv1.SubjectAccessReviewSpec) (bool, error)
+}
+
+func (p accessReviewClientProvider) NewServiceAccountClient(context.Context) (kubernetes.Interface, *rest.Config, error) {
+	client := fake.NewSimpleClientset(p.pods...)
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		allowed, err := p.review(review.Spec)
+		if err != nil {
+			return true, nil, err
+		}
+		return true, &authorizationv1.SubjectAccessReview{
+			Status: authorizationv1.SubjectAccessReviewStatus{Allowed: allowed},
+		}, nil
+	})
+	return client, &rest.Config{}, nil
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var userGVR = schema.GroupVersionResource{
+	Group:    "user.openshift.io",
+	Version:  "v1",
+	Resource: "users",
+}
+
+// UserInfo describes the user that owns a token.
+type UserInfo struct {
+	UID      string
+	Username string
+	Groups   []string
+	// Extra is additional information about the user reported by the authentication strategy, e.g. the scopes
+	// of an OpenShift token in scopes.authorization.openshift.io, which restrict what the token is authorized
+	// to do. Nil if the strategy does not report it.
+	Extra map[string][]string
+}
+
+// Authenticator resolves the user that owns a token.
+type Authenticator interface {
+	// Name returns the name of the strategy used by the authenticator, for use in errors
+	Name() string
+	// Authenticate returns the user that owns token, or an error if the user cannot be determined
+	Authenticate(ctx context.Context, token string) (*UserInfo, error)
+}
+
+// NewAuthenticator returns an Authenticator that tries each of strategies in order until one succeeds.
+// Strategies are the values supported by config.AuthStrategies.
+func NewAuthenticator(strategies []string, clientProvider operations.ClientProvider) (Authenticator, error) {
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("at least one authentication strategy must be specified")
+	}
+	var chain chainAuthenticator
+	for _, strategy := range strategies {
+		switch strategy {
+		case config.AuthStrategyOpenShiftUser:
+			chain = append(chain, &openShiftUserAuthenticator{clientProvider})
+		case config.AuthStrategySelfSubjectReview:
+			chain = append(chain, &selfSubjectReviewAuthenticator{clientProvider})
+		case config.AuthStrategyTokenReview:
+			chain = append(chain, &tokenReviewAuthenticator{clientProvider})
+		default:
+			return nil, fmt.Errorf("unknown authentication strategy '%s'", strategy)
+		}
+	}
+	return chain, nil
+}
+
+// DefaultAuthenticator returns an Authenticator that uses the OpenShift User API, falling back to
+// SelfSubjectReview on clusters where the OpenShift User API is unavailable (e.g. BYO external
+// authentication without user.openshift.io).
+func DefaultAuthenticator(clientProvider operations.ClientProvider) Authenticator {
+	return chainAuthenticator{
+		&openShiftUserAuthenticator{clientProvider},
+		&selfSubjectReviewAuthenticator{clientProvider},
+	}
+}
+
+// chainAuthenticator tries each authenticator in order, returning the first successful result.
+type chainAuthenticator []Authenticator
+
+func (c chainAuthenticator) Name() string {
+	names := make([]string, len(c))
+	for idx, authenticator := range c {
+		names[idx] = authenticator.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c chainAuthenticator) Authenticate(ctx context.Context, token string) (*UserInfo, error) {
+	var errFormats []string
+	var errs []interface{}
+	for _, authenticator := range c {
+		user, err := authenticator.Authenticate(ctx, token)
+		if err == nil {
+			return user, nil
+		}
+		errFormats = append(errFormats, authenticator.Name()+" error: %w")
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("failed to get current user information: "+strings.Join(errFormats, "; "), errs...)
+}
+
+// openShiftUserAuthenticator gets the current user from the OpenShift User API using the user's token.
+type openShiftUserAuthenticator struct {
+	clientProvider operations.ClientProvider
+}
+
+func (*openShiftUserAuthenticator) Name() string {
+	return "OpenShift User API"
+}
+
+func (a *openShiftUserAuthenticator) Authenticate(ctx context.Context, token string) (*UserInfo, error) {
+	userClient, _, err := a.clientProvider.NewOpenShiftUserClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.UserLookupTimeout)
+	defer cancel()
+	user, err := userClient.Resource(userGVR).Namespace("").Get(ctx, "~", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	groups, _, err := unstructured.NestedStringSlice(user.Object, "groups")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user groups: %w", err)
+	}
+
+	// kube:admin / kubeadmin have no Kubernetes UID; empty string is a valid identifier
+	// when AUTHENTICATED_USER_ID is also empty (see config.AuthenticatedUserID).
+	// The User API does not report the scopes of the token, so Extra is not set; this strategy is therefore
+	// rejected by config when users are authorized with SubjectAccessReviews.
+	return &UserInfo{
+		UID:      string(user.GetUID()),
+		Username: user.GetName(),
+		Groups:   groups,
+	}, nil
+}
+
+// selfSubjectReviewAuthenticator gets the current user from a SelfSubjectReview using the user's token.
+type selfSubjectReviewAuthenticator struct {
+	clientProvider operations.ClientProvider
+}
+
+func (*selfSubjectReviewAuthenticator) Name() string {
+	return "SelfSubjectReview"
+}
+
+func (a *selfSubjectReviewAuthenticator) Authenticate(ctx context.Context, token string) (*UserInfo, error) {
+	client, _, err := a.clientProvider.NewClientWithToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client to check user info: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.UserLookupTimeout)
+	defer cancel()
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if review.Status.UserInfo.UID == "" {
+		return nil, fmt.Errorf("SelfSubjectReview returned empty UID")
+	}
+	return userInfoFromReview(review.Status.UserInfo), nil
+}
+
+// tokenReviewAuthenticator reviews the user's token with a TokenReview using the server's service account,
+// which must be allowed to create tokenreviews (e.g. via the system:auth-delegator cluster role).
+type tokenReviewAuthenticator struct {
+	clientProvider operations.ClientProvider
+}
+
+func (*tokenReviewAuthenticator) Name() string {
+	return "TokenReview"
+}
+
+func (a *tokenReviewAuthenticator) Authenticate(ctx context.Context, token string) (*UserInfo, error) {
+	client, _, err := a.clientProvider.NewServiceAccountClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client to review token: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.UserLookupTimeout)
+	defer cancel()
+	review, err := client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, fmt.Errorf("token is not authenticated: %s", review.Status.Error)
+		}
+		return nil, fmt.Errorf("token is not authenticated")
+	}
+	if review.Status.User.UID == "" {
+		return nil, fmt.Errorf("TokenReview returned empty UID")
+	}
+	return userInfoFromReview(review.Status.User), nil
+}
+
+func userInfoFromReview(user authenticationv1.UserInfo) *UserInfo {
+	var extra map[string][]string
+	if len(user.Extra) > 0 {
+		extra = make(map[string][]string, len(user.Extra))
+		for key, value := range user.Extra {
+			extra[key] = []string(value)
+		}
+	}
+	return &UserInfo{
+		UID:      user.UID,
+		Username: user.Username,
+		Groups:   user.Groups,
+		Extra:    extra,
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDefaultAuthenticator(t *testing.T) {
+	const expectedUID = "test-user-uid"
+	tests := []struct {
+		name        string
+		provider    operations.ClientProvider
+		errRegexp   string
+		expectedUID string
+	}{
+		{
+			name: "Should return UID from OpenShift User API",
+			provider: testUserIDClientProvider{
+				userAPIUID: expectedUID,
+			},
+			expectedUID: expectedUID,
+		},
+		{
+			name: "Should fall back to SelfSubjectReview when OpenShift User API is unavailable",
+			provider: testUserIDClientProvider{
+				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
+				userUID:            expectedUID,
+			},
+			expectedUID: expectedUID,
+		},
+		{
+			name: "Should return error when client creation fails",
+			provider: testUserIDClientProvider{
+				returnClientError: true,
+			},
+			errRegexp: "failed to create client to check user info",
+		},
+		{
+			name: "Should return error when both user lookups fail",
+			provider: testUserIDClientProvider{
+				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
+				returnReviewError:  apierrors.NewNotFound(schema.GroupResource{Group: "authentication.k8s.io", Resource: "selfsubjectreviews"}, "self"),
+			},
+			errRegexp: "failed to get current user information",
+		},
+		{
+			name: "Should allow empty UID from OpenShift User API for kube:admin",
+			provider: testUserIDClientProvider{
+				userAPIUID:      "",
+				emptyUserAPIUID: true,
+			},
+			expectedUID: "",
+		},
+		{
+			name: "Should return error when SelfSubjectReview returns empty UID on fallback",
+			provider: testUserIDClientProvider{
+				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
+				userUID:            "",
+			},
+			errRegexp: "SelfSubjectReview returned empty UID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := DefaultAuthenticator(tt.provider).Authenticate(context.Background(), "test-token")
+			if tt.errRegexp != "" {
+				assert.Error(t, err)
+				assert.Regexp(t, tt.errRegexp, err.Error())
+				if tt.name == "Should return error when both user lookups fail" {
+					assert.Contains(t, err.Error(), "OpenShift User API error:")
+					assert.Contains(t, err.Error(), "SelfSubjectReview error:")
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedUID, user.UID)
+		})
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	provider := testUserIDClientProvider{
+		userAPIUID: "openshift-uid",
+		userUID:    "ssr-uid",
+		tokenReview: &authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{
+			UID:      "token-review-uid",
+			Username: "test-user",
+			Groups:   []string{"test-group"},
+			Extra:    map[string]authenticationv1.ExtraValue{"scopes.authorization.openshift.io": {"user:info"}},
+		}},
+	}
+	tests := []struct {
+		strategies  []string
+		expectedUID string
+	}{
+		{strategies: []string{config.AuthStrategyOpenShiftUser, config.AuthStrategySelfSubjectReview}, expectedUID: "openshift-uid"},
+		{strategies: []string{config.AuthStrategySelfSubjectReview, config.AuthStrategyOpenShiftUser}, expectedUID: "ssr-uid"},
+		{strategies: []string{config.AuthStrategyTokenReview}, expectedUID: "token-review-uid"},
+	}
+	for _, tt := range tests {
+		authenticator, err := NewAuthenticator(tt.strategies, provider)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		user, err := authenticator.Authenticate(context.Background(), "test-token")
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expectedUID, user.UID, "Should use first strategy in %v", tt.strategies)
+	}
+
+	authenticator, err := NewAuthenticator([]string{config.AuthStrategyTokenReview}, provider)
+	assert.NoError(t, err)
+	user, err := authenticator.Authenticate(context.Background(), "test-token")
+	assert.NoError(t, err)
+	assert.Equal(t, &UserInfo{
+		UID:      "token-review-uid",
+		Username: "test-user",
+		Groups:   []string{"test-group"},
+		Extra:    map[string][]string{"scopes.authorization.openshift.io": {"user:info"}},
+	}, user, "Should report token scopes")
+
+	_, err = NewAuthenticator(nil, provider)
+	assert.Error(t, err)
+	_, err = NewAuthenticator([]string{"unknown"}, provider)
+	assert.Error(t, err)
+	assert.Regexp(t, "unknown authentication strategy 'unknown'", err.Error())
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    *authenticationv1.TokenReviewStatus
+		errRegexp string
+	}{
+		{
+			name:      "Token not authenticated",
+			status:    &authenticationv1.TokenReviewStatus{Authenticated: false, Error: "token expired"},
+			errRegexp: "token is not authenticated: token expired",
+		},
+		{
+			name:      "Empty UID",
+			status:    &authenticationv1.TokenReviewStatus{Authenticated: true},
+			errRegexp: "TokenReview returned empty UID",
+		},
+		{
+			name:      "Service account client creation fails",
+			errRegexp: "failed to create client to review token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := testUserIDClientProvider{tokenReview: tt.status, returnClientError: tt.status == nil}
+			authenticator, err := NewAuthenticator([]string{config.AuthStrategyTokenReview}, provider)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = authenticator.Authenticate(context.Background(), "test-token")
+			assert.Error(t, err)
+			assert.Regexp(t, tt.errRegexp, err.Error())
+			assert.Regexp(t, "TokenReview error:", err.Error())
+		})
+	}
+}
+
+type testUserIDClientProvider struct {
+	userUID            string
+	userAPIUID         string
+	returnClientError  bool
+	returnReviewError  error
+	returnUserAPIError error
+	emptyUserAPIUID    bool
+	tokenReview        *authenticationv1.TokenReviewStatus
+}
+
+func (p testUserIDClientProvider) NewDevWorkspaceClient(context.Context) (dynamic.Interface, *rest.Config, error) {
+	return nil, nil, nil
+}
+
+func (p testUserIDClientProvider) NewClientWithToken(context.Context, string) (kubernetes.Interface, *rest.Config, error) {
+	if p.returnClientError {
+		return nil, nil, fmt.Errorf("(TEST) expected error")
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+		if p.returnReviewError != nil {
+			return true, nil, p.returnReviewError
+		}
+		return true, &authenticationv1.SelfSubjectReview{
+			Status: authenticationv1.SelfSubjectReviewStatus{
+				UserInfo: authenticationv1.UserInfo{
+					UID: p.userUID,
+				},
+			},
+		}, nil
+	})
+	return client, &rest.Config{}, nil
+}
+
+func (p testUserIDClientProvider) NewOpenShiftUserClient(context.Context, string) (dynamic.Interface, *rest.Config, error) {
+	if p.returnUserAPIError != nil {
+		return fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}), &rest.Config{}, nil
+	}
+	if p.userAPIUID == "" && !p.emptyUserAPIUID {
+		return nil, nil, fmt.Errorf("(TEST) OpenShift User API not configured")
+	}
+	fakeUser := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "~",
+				"uid":  p.userAPIUID,
+			},
+		},
+	}
+	fakeUser.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "user.openshift.io",
+		Version: "v1",
+		Kind:    "User",
+	})
+	client := fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}, fakeUser)
+	return client, &rest.Config{}, nil
+}
+
+func (p testUserIDClientProvider) NewServiceAccountClient(context.Context) (kubernetes.Interface, *rest.Config, error) {
+	if p.returnClientError {
+		return nil, nil, fmt.Errorf("(TEST) expected error")
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+		if p.tokenReview == nil {
+			return true, nil, fmt.Errorf("(TEST) TokenReview not configured")
+		}
+		return true, &authenticationv1.TokenReview{Status: *p.tokenReview}, nil
+	})
+	return client, &rest.Config{}, nil
+}
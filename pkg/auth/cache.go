@@ -0,0 +1,193 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
+)
+
+// UserCache memoizes the user resolved for a token and their access level, to avoid calling the API server on
+// every request.
+// Failed lookups are also cached, for a shorter time. Entries are keyed by a hash of the token, so tokens
+// are not retained in memory. Users are not cached beyond the expiry of a JWT token. Once the cache is full,
+// the least recently used entry is evicted.
+type UserCache struct {
+	mutex       sync.Mutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+	entries     map[string]*list.Element
+	// lru orders entries from most to least recently used
+	lru *list.List
+	now func() time.Time
+}
+
+type userCacheEntry struct {
+	key     string
+	user    *UserInfo
+	err     error
+	expires time.Time
+	// access is the access level of user, if it has been determined
+	access    AccessLevel
+	hasAccess bool
+}
+
+// NewUserCache returns a UserCache that caches users for ttl and failed lookups for negativeTTL, holding at
+// most maxEntries entries. Returns nil, which disables caching, if ttl or maxEntries is not positive.
+func NewUserCache(ttl, negativeTTL time.Duration, maxEntries int) *UserCache {
+	if ttl <= 0 || maxEntries <= 0 {
+		return nil
+	}
+	return &UserCache{
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		maxEntries:  maxEntries,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		now:         time.Now,
+	}
+}
+
+// Lookup returns the cached result for token, calling lookup and caching its result if there is no
+// unexpired entry. If the cache is nil, lookup is always called.
+func (c *UserCache) Lookup(ctx context.Context, token string, lookup func(ctx context.Context, token string) (*UserInfo, error)) (*UserInfo, error) {
+	if c == nil {
+		return lookup(ctx, token)
+	}
+	key := hashToken(token)
+	if entry, ok := c.get(key); ok {
+		if entry.err != nil {
+			metrics.AuthCacheLookup(metrics.AuthCacheResultNegativeHit)
+		} else {
+			metrics.AuthCacheLookup(metrics.AuthCacheResultHit)
+		}
+		return entry.user, entry.err
+	}
+	metrics.AuthCacheLookup(metrics.AuthCacheResultMiss)
+
+	user, err := lookup(ctx, token)
+	if err != nil && ctx.Err() != nil {
+		// Do not cache failures caused by the request being cancelled
+		return user, err
+	}
+	c.set(key, user, err, TokenExpiry(token))
+	return user, err
+}
+
+// Authorize returns the cached access level of user, who was returned by Lookup for token, calling authorize and
+// caching its result alongside the user if there is none. Access levels expire together with the user, and
+// errors are not cached. If the cache is nil, authorize is always called.
+func (c *UserCache) Authorize(ctx context.Context, token string, user *UserInfo, authorize func(ctx context.Context, user *UserInfo) (AccessLevel, error)) (AccessLevel, error) {
+	if c == nil {
+		return authorize(ctx, user)
+	}
+	key := hashToken(token)
+	if entry, ok := c.get(key); ok && entry.user == user && entry.hasAccess {
+		return entry.access, nil
+	}
+	access, err := authorize(ctx, user)
+	if err != nil {
+		return access, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// The entry may have been replaced or evicted while authorizing; only cache access for the same user
+	if elem, ok := c.entries[key]; ok {
+		if entry := elem.Value.(*userCacheEntry); entry.user == user {
+			entry.access, entry.hasAccess = access, true
+		}
+	}
+	return access, nil
+}
+
+// Invalidate removes the cached user and access level for token, so that the next request with token is
+// authenticated against the API server again. It does nothing if the cache is nil.
+func (c *UserCache) Invalidate(token string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, ok := c.entries[hashToken(token)]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *UserCache) get(key string) (*userCacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*userCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	// Return a copy, as the access level of the entry may be updated once the mutex is released
+	entryCopy := *entry
+	return &entryCopy, true
+}
+
+// set caches the result of looking up a token. Successful lookups expire after the TTL, or when the token
+// expires if that is sooner, so that expired tokens are not accepted from the cache.
+func (c *UserCache) set(key string, user *UserInfo, err error, tokenExpiry *time.Time) {
+	ttl := c.ttl
+	if err != nil {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	expires := now.Add(ttl)
+	if err == nil && tokenExpiry != nil && tokenExpiry.Before(expires) {
+		expires = *tokenExpiry
+	}
+	if !now.Before(expires) {
+		return
+	}
+	entry := &userCacheEntry{key: key, user: user, err: err, expires: expires}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+		metrics.AuthCacheEvicted()
+	}
+	metrics.SetAuthCacheEntries(c.lru.Len())
+}
+
+// removeElement removes elem from the cache. Must be called with the mutex held.
+func (c *UserCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*userCacheEntry).key)
+	metrics.SetAuthCacheEntries(c.lru.Len())
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLookup returns a lookup function that returns a user with the token's UID from uids, or an error if the
+// token is not in uids, and counts how many times it is called per token.
+func countingLookup(uids map[string]string, calls map[string]int) func(context.Context, string) (*UserInfo, error) {
+	return func(_ context.Context, token string) (*UserInfo, error) {
+		calls[token]++
+		uid, ok := uids[token]
+		if !ok {
+			return nil, fmt.Errorf("invalid token")
+		}
+		return &UserInfo{UID: uid}, nil
+	}
+}
+
+func TestUserCache(t *testing.T) {
+	now := time.Now()
+	cache := NewUserCache(time.Minute, 10*time.Second, 10)
+	cache.now = func() time.Time { return now }
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{"valid-token": "test-uid"}, calls)
+
+	for i := 0; i < 3; i++ {
+		user, err := cache.Lookup(context.Background(), "valid-token", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-uid", user.UID)
+		_, err = cache.Lookup(context.Background(), "invalid-token", lookup)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, calls["valid-token"], "Should cache successful lookups")
+	assert.Equal(t, 1, calls["invalid-token"], "Should cache failed lookups")
+
+	now = now.Add(30 * time.Second)
+	_, err := cache.Lookup(context.Background(), "valid-token", lookup)
+	assert.NoError(t, err)
+	_, err = cache.Lookup(context.Background(), "invalid-token", lookup)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls["valid-token"], "Should not expire successful lookups before TTL")
+	assert.Equal(t, 2, calls["invalid-token"], "Should expire failed lookups after negative TTL")
+
+	now = now.Add(time.Minute)
+	_, err = cache.Lookup(context.Background(), "valid-token", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls["valid-token"], "Should expire successful lookups after TTL")
+
+	assert.NotContains(t, cache.entries, "valid-token", "Should not store tokens in cache")
+	assert.Contains(t, cache.entries, hashToken("valid-token"))
+}
+
+func TestUserCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewUserCache(time.Minute, time.Minute, 2)
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{"first": "1", "second": "2", "third": "3"}, calls)
+	ctx := context.Background()
+
+	_, _ = cache.Lookup(ctx, "first", lookup)
+	_, _ = cache.Lookup(ctx, "second", lookup)
+	_, _ = cache.Lookup(ctx, "first", lookup)
+	_, _ = cache.Lookup(ctx, "third", lookup)
+	assert.Len(t, cache.entries, 2, "Should not exceed maximum size")
+
+	_, _ = cache.Lookup(ctx, "first", lookup)
+	_, _ = cache.Lookup(ctx, "second", lookup)
+	assert.Equal(t, 1, calls["first"], "Recently used entry should not be evicted")
+	assert.Equal(t, 2, calls["second"], "Least recently used entry should be evicted")
+}
+
+func TestUserCacheAuthorize(t *testing.T) {
+	now := time.Now()
+	cache := NewUserCache(time.Minute, 10*time.Second, 10)
+	cache.now = func() time.Time { return now }
+	lookup := countingLookup(map[string]string{"test-token": "test-uid"}, map[string]int{})
+	authorizeCalls := 0
+	var authorizeErr error
+	authorize := func(_ context.Context, user *UserInfo) (AccessLevel, error) {
+		authorizeCalls++
+		return AccessFull, authorizeErr
+	}
+	ctx := context.Background()
+
+	authorizeErr = fmt.Errorf("test error")
+	user, _ := cache.Lookup(ctx, "test-token", lookup)
+	_, err := cache.Authorize(ctx, "test-token", user, authorize)
+	assert.Error(t, err)
+	authorizeErr = nil
+	for i := 0; i < 3; i++ {
+		user, _ := cache.Lookup(ctx, "test-token", lookup)
+		access, err := cache.Authorize(ctx, "test-token", user, authorize)
+		assert.NoError(t, err)
+		assert.Equal(t, AccessFull, access)
+	}
+	assert.Equal(t, 2, authorizeCalls, "Should cache access levels but not errors")
+
+	now = now.Add(2 * time.Minute)
+	user, _ = cache.Lookup(ctx, "test-token", lookup)
+	_, _ = cache.Authorize(ctx, "test-token", user, authorize)
+	assert.Equal(t, 3, authorizeCalls, "Should expire access levels with user")
+
+	var nilCache *UserCache
+	_, _ = nilCache.Authorize(ctx, "test-token", user, authorize)
+	_, _ = nilCache.Authorize(ctx, "test-token", user, authorize)
+	assert.Equal(t, 5, authorizeCalls, "Nil cache should always call authorize")
+}
+
+func TestUserCacheExpiresWithToken(t *testing.T) {
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewUserCache(time.Minute, time.Minute, 10)
+	cache.now = func() time.Time { return now }
+	jwt := func(exp time.Time) string {
+		payload := fmt.Sprintf(`{"sub": "test", "exp": %d}`, exp.Unix())
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+	expiringToken := jwt(now.Add(20 * time.Second))
+	expiredToken := jwt(now.Add(-time.Second))
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{expiringToken: "test-uid", expiredToken: "test-uid"}, calls)
+
+	_, _ = cache.Lookup(context.Background(), expiringToken, lookup)
+	_, _ = cache.Lookup(context.Background(), expiringToken, lookup)
+	assert.Equal(t, 1, calls[expiringToken], "Should cache lookups until token expires")
+	now = now.Add(30 * time.Second)
+	_, _ = cache.Lookup(context.Background(), expiringToken, lookup)
+	assert.Equal(t, 2, calls[expiringToken], "Should expire lookups when token expires, before TTL")
+
+	_, _ = cache.Lookup(context.Background(), expiredToken, lookup)
+	_, _ = cache.Lookup(context.Background(), expiredToken, lookup)
+	assert.Equal(t, 2, calls[expiredToken], "Should not cache lookups for expired tokens")
+}
+
+func TestUserCacheDoesNotCacheCancelledLookups(t *testing.T) {
+	cache := NewUserCache(time.Minute, time.Minute, 10)
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{}, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.Lookup(ctx, "test-token", lookup)
+	assert.Error(t, err)
+	_, err = cache.Lookup(context.Background(), "test-token", lookup)
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls["test-token"], "Should not cache failures caused by cancelled requests")
+}
+
+func TestUserCacheInvalidate(t *testing.T) {
+	cache := NewUserCache(time.Minute, 10*time.Second, 10)
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{"test-token": "test-uid", "other-token": "other-uid"}, calls)
+	for _, token := range []string{"test-token", "other-token"} {
+		_, err := cache.Lookup(context.Background(), token, lookup)
+		assert.NoError(t, err)
+	}
+
+	cache.Invalidate("test-token")
+	cache.Invalidate("unknown-token")
+	for _, token := range []string{"test-token", "other-token"} {
+		_, err := cache.Lookup(context.Background(), token, lookup)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, calls["test-token"], "Should look up invalidated token again")
+	assert.Equal(t, 1, calls["other-token"], "Should keep other tokens cached")
+
+	var disabled *UserCache
+	disabled.Invalidate("test-token")
+}
+
+func TestUserCacheDisabled(t *testing.T) {
+	assert.Nil(t, NewUserCache(0, time.Minute, 10), "Should disable cache if TTL is 0")
+	assert.Nil(t, NewUserCache(time.Minute, time.Minute, 0), "Should disable cache if size is 0")
+
+	var cache *UserCache
+	calls := map[string]int{}
+	lookup := countingLookup(map[string]string{"test-token": "test-uid"}, calls)
+	for i := 0; i < 2; i++ {
+		user, err := cache.Lookup(context.Background(), "test-token", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-uid", user.UID)
+	}
+	assert.Equal(t, 2, calls["test-token"], "Nil cache should always call lookup")
+}
@@ -11,27 +11,116 @@
 package auth
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 )
 
 const (
 	accessTokenHeader          = "X-Access-Token"
 	forwardedAccessTokenHeader = "X-Forwarded-Access-Token"
+	// requestedWithHeader must be set on requests that change state and read the token from a cookie. Browsers
+	// only send custom headers cross-site after a CORS preflight, which this server does not allow, so the header
+	// protects cookie-authenticated requests against cross-site request forgery.
+	requestedWithHeader = "X-Requested-With"
 )
 
+// defaultTokenSources are used if config.TokenSources is not set
+var defaultTokenSources = []config.TokenSource{
+	{Kind: config.TokenSourceHeader, Name: accessTokenHeader},
+	{Kind: config.TokenSourceHeader, Name: forwardedAccessTokenHeader},
+}
+
+// ExtractToken returns the user's token from the first source in config.TokenSources that is present in the
+// request. Returns an error if no source is present, or if explicitly configured sources contain different
+// tokens; with the default sources, X-Access-Token is preferred over X-Forwarded-Access-Token. Requests that read
+// the token from a cookie must set the X-Requested-With header unless their method is GET, HEAD or OPTIONS.
 func ExtractToken(r *http.Request) (string, error) {
-	token := r.Header.Get(accessTokenHeader)
-	if token != "" {
-		token = strings.TrimPrefix(token, "Bearer ")
-		return token, nil
+	sources := config.TokenSources
+	if len(sources) == 0 {
+		sources = defaultTokenSources
+	}
+	checkConflicts := !slices.Equal(sources, defaultTokenSources)
+	var token string
+	var tokenSource config.TokenSource
+	for _, source := range sources {
+		sourceToken := tokenFromSource(r, source)
+		if sourceToken == "" {
+			continue
+		}
+		if token == "" {
+			token, tokenSource = sourceToken, source
+		} else if checkConflicts && sourceToken != token {
+			return "", fmt.Errorf("request contains conflicting tokens in %s and %s", tokenSource, source)
+		}
 	}
+	if token == "" {
+		return "", fmt.Errorf("authorization header is missing")
+	}
+	if tokenSource.Kind == config.TokenSourceCookie && !isSafeMethod(r.Method) && r.Header.Get(requestedWithHeader) == "" {
+		return "", fmt.Errorf("%s header is required when the token is read from %s", requestedWithHeader, tokenSource)
+	}
+	return token, nil
+}
 
-	token = r.Header.Get(forwardedAccessTokenHeader)
-	if token != "" {
-		return token, nil
+// isSafeMethod returns whether method is one that does not change state on the server
+func isSafeMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
 	}
+	return false
+}
 
-	return "", fmt.Errorf("authorization header is missing")
+func tokenFromSource(r *http.Request, source config.TokenSource) string {
+	switch source.Kind {
+	case config.TokenSourceAuthorization:
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	case config.TokenSourceCookie:
+		cookie, err := r.Cookie(source.Name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	default:
+		token := r.Header.Get(source.Name)
+		// X-Forwarded-Access-Token is set by oauth-proxy to the raw token; other headers may be prefixed
+		// with the Bearer scheme
+		if source.Name != forwardedAccessTokenHeader {
+			token = strings.TrimPrefix(token, "Bearer ")
+		}
+		return token
+	}
+}
+
+// TokenExpiry returns the expiry time of token if it is a JWT with an 'exp' claim (e.g. a service account or
+// OIDC token). Returns nil for tokens whose expiry cannot be determined, such as OpenShift OAuth access tokens.
+// The token's signature is not verified.
+func TokenExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return nil
+	}
+	expiry := time.Unix(int64(*claims.Exp), 0).UTC()
+	return &expiry
 }
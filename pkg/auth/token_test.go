@@ -11,9 +11,12 @@
 package auth
 
 import (
+	"encoding/base64"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,7 +27,9 @@ const (
 func TestExtractToken(t *testing.T) {
 	tests := []struct {
 		name      string
+		method    string
 		headers   http.Header
+		sources   []config.TokenSource
 		expected  string
 		errRegexp string
 	}{
@@ -53,10 +58,99 @@ func TestExtractToken(t *testing.T) {
 			headers:  http.Header{"X-Forwarded-Access-Token": []string{"Bearer " + testToken}},
 			expected: "Bearer " + testToken,
 		},
+		{
+			name: "Accepts same token in multiple headers",
+			headers: http.Header{
+				"X-Access-Token":           []string{"Bearer " + testToken},
+				"X-Forwarded-Access-Token": []string{testToken},
+			},
+			expected: testToken,
+		},
+		{
+			name: "Prefers X-Access-Token if default headers contain different tokens",
+			headers: http.Header{
+				"X-Access-Token":           []string{testToken},
+				"X-Forwarded-Access-Token": []string{"other-token"},
+			},
+			expected: testToken,
+		},
+		{
+			name:      "Ignores Authorization header by default",
+			headers:   http.Header{"Authorization": []string{"Bearer " + testToken}},
+			errRegexp: "authorization header is missing",
+		},
+		{
+			name:     "Extracts from Authorization header",
+			headers:  http.Header{"Authorization": []string{"bearer " + testToken}},
+			sources:  []config.TokenSource{{Kind: config.TokenSourceAuthorization}},
+			expected: testToken,
+		},
+		{
+			name:      "Ignores Authorization header with other schemes",
+			headers:   http.Header{"Authorization": []string{"Basic dXNlcjpwYXNz"}},
+			sources:   []config.TokenSource{{Kind: config.TokenSourceAuthorization}},
+			errRegexp: "authorization header is missing",
+		},
+		{
+			name:     "Extracts from cookie",
+			headers:  http.Header{"Cookie": []string{"other=value; _oauth_token=" + testToken}},
+			sources:  []config.TokenSource{{Kind: config.TokenSourceCookie, Name: "_oauth_token"}},
+			expected: testToken,
+		},
+		{
+			name:      "Error if cookie is used for POST without X-Requested-With header",
+			method:    http.MethodPost,
+			headers:   http.Header{"Cookie": []string{"_oauth_token=" + testToken}},
+			sources:   []config.TokenSource{{Kind: config.TokenSourceCookie, Name: "_oauth_token"}},
+			errRegexp: "X-Requested-With header is required when the token is read from cookie _oauth_token",
+		},
+		{
+			name:   "Extracts from cookie for POST with X-Requested-With header",
+			method: http.MethodPost,
+			headers: http.Header{
+				"Cookie":           []string{"_oauth_token=" + testToken},
+				"X-Requested-With": []string{"XMLHttpRequest"},
+			},
+			sources:  []config.TokenSource{{Kind: config.TokenSourceCookie, Name: "_oauth_token"}},
+			expected: testToken,
+		},
+		{
+			name:   "Does not require X-Requested-With header if token is in a header",
+			method: http.MethodDelete,
+			headers: http.Header{
+				"Authorization": []string{"Bearer " + testToken},
+				"Cookie":        []string{"_oauth_token=" + testToken},
+			},
+			sources: []config.TokenSource{
+				{Kind: config.TokenSourceAuthorization},
+				{Kind: config.TokenSourceCookie, Name: "_oauth_token"},
+			},
+			expected: testToken,
+		},
+		{
+			name:     "Extracts from configured header",
+			headers:  http.Header{"X-Auth-Request-Access-Token": []string{testToken}},
+			sources:  []config.TokenSource{{Kind: config.TokenSourceHeader, Name: "X-Auth-Request-Access-Token"}},
+			expected: testToken,
+		},
+		{
+			name: "Error if configured sources contain conflicting tokens",
+			headers: http.Header{
+				"Authorization": []string{"Bearer " + testToken},
+				"Cookie":        []string{"_oauth_token=other-token"},
+			},
+			sources: []config.TokenSource{
+				{Kind: config.TokenSourceAuthorization},
+				{Kind: config.TokenSourceCookie, Name: "_oauth_token"},
+			},
+			errRegexp: "request contains conflicting tokens in Authorization header and cookie _oauth_token",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := &http.Request{Header: tt.headers}
+			config.TokenSources = tt.sources
+			defer config.ResetConfigForTest()
+			req := &http.Request{Method: tt.method, Header: tt.headers}
 			result, err := ExtractToken(req)
 			if tt.errRegexp != "" {
 				assert.Error(t, err)
@@ -68,3 +162,16 @@ func TestExtractToken(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenExpiry(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+	expiry := TokenExpiry(jwt(`{"sub": "system:serviceaccount:test:default", "exp": 1893456000}`))
+	if assert.NotNil(t, expiry, "Should read expiry from JWT") {
+		assert.Equal(t, time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), *expiry)
+	}
+	assert.Nil(t, TokenExpiry(jwt(`{"sub": "test"}`)), "Should return nil for JWT without exp claim")
+	assert.Nil(t, TokenExpiry("sha256~abcdefghijklmnop"), "Should return nil for opaque tokens")
+	assert.Nil(t, TokenExpiry("a.b.c"), "Should return nil for invalid JWT")
+}
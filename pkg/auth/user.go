@@ -11,28 +11,51 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/config"
-	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
-	"github.com/sirupsen/logrus"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
 )
 
-func Authenticate(r *http.Request, clientProvider operations.ClientProvider) error {
+// Authenticate identifies the user that owns the token in the request and returns the user and their access
+// level as determined by authorizer. Returns an error if the user cannot be identified or is not allowed to access
+// this terminal. If cache is not nil, the user and their access level are looked up in the cache before calling
+// the API server.
+func Authenticate(r *http.Request, authenticator Authenticator, authorizer Authorizer, cache *UserCache) (*UserInfo, AccessLevel, error) {
 	token, err := ExtractToken(r)
 	if err != nil {
-		return err
+		return nil, AccessNone, err
 	}
-	uid, err := operations.GetCurrentUserUID(token, clientProvider)
+	log := logging.FromContext(r.Context())
+	user, err := cache.Lookup(r.Context(), token, authenticator.Authenticate)
 	if err != nil {
-		logrus.Errorf("Unable to verify user: %v", err)
-		return fmt.Errorf("unable to verify user")
+		log.Errorf("Unable to verify user: %v", err)
+		return nil, AccessNone, fmt.Errorf("unable to verify user")
 	}
-	if uid != config.AuthenticatedUserID {
-		logrus.Debugf("User failed to authenticate: authorized user = '%s', requested user = '%s'", config.AuthenticatedUserID, uid)
-		return fmt.Errorf("the current user is not authorized to access this web terminal")
+	access, err := cache.Authorize(r.Context(), token, user, authorizer.Authorize)
+	if err != nil {
+		log.Errorf("Unable to verify access of user '%s': %v", user.UID, err)
+		return nil, AccessNone, fmt.Errorf("unable to verify user")
+	}
+	if access == AccessNone {
+		log.Debugf("User failed to authenticate: authorized user = '%s', requested user = '%s' (username '%s')", config.AuthenticatedUserID, user.UID, user.Username)
+		return nil, AccessNone, fmt.Errorf("the current user is not authorized to access this web terminal")
 	}
-	logrus.Debugf("User '%s' authenticated", uid)
-	return nil
+	log.Debugf("User '%s' (username '%s') authenticated with %s access", user.UID, user.Username, access)
+	return user, access, nil
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx that carries user, the user authenticated for a request.
+func WithUser(ctx context.Context, user *UserInfo) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user authenticated for the request that ctx belongs to, or nil if there is none.
+func UserFromContext(ctx context.Context) *UserInfo {
+	user, _ := ctx.Value(userKey{}).(*UserInfo)
+	return user
 }
@@ -11,6 +11,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -68,7 +69,7 @@ func TestAuthenticate(t *testing.T) {
 				}
 			}
 			req := &http.Request{Header: tt.headers}
-			err := Authenticate(req, clientProvider)
+			_, _, err := Authenticate(req, DefaultAuthenticator(clientProvider), DefaultAuthorizer(), nil)
 			if tt.errRegexp != "" {
 				assert.Error(t, err)
 				assert.Regexp(t, tt.errRegexp, err.Error())
@@ -84,18 +85,19 @@ func TestAuthenticate(t *testing.T) {
 }
 
 type operationsClientProvider interface {
-	NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error)
-	NewClientWithToken(token string) (kubernetes.Interface, *rest.Config, error)
-	NewOpenShiftUserClient(token string) (dynamic.Interface, *rest.Config, error)
+	NewDevWorkspaceClient(ctx context.Context) (dynamic.Interface, *rest.Config, error)
+	NewClientWithToken(ctx context.Context, token string) (kubernetes.Interface, *rest.Config, error)
+	NewOpenShiftUserClient(ctx context.Context, token string) (dynamic.Interface, *rest.Config, error)
+	NewServiceAccountClient(ctx context.Context) (kubernetes.Interface, *rest.Config, error)
 }
 
 type selfSubjectReviewErrorClientProvider struct{}
 
-func (selfSubjectReviewErrorClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error) {
+func (selfSubjectReviewErrorClientProvider) NewDevWorkspaceClient(context.Context) (dynamic.Interface, *rest.Config, error) {
 	return nil, nil, nil
 }
 
-func (selfSubjectReviewErrorClientProvider) NewClientWithToken(string) (kubernetes.Interface, *rest.Config, error) {
+func (selfSubjectReviewErrorClientProvider) NewClientWithToken(context.Context, string) (kubernetes.Interface, *rest.Config, error) {
 	client := fake.NewSimpleClientset()
 	client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
 		return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "authentication.k8s.io", Resource: "selfsubjectreviews"}, "self")
@@ -103,6 +105,10 @@ func (selfSubjectReviewErrorClientProvider) NewClientWithToken(string) (kubernet
 	return client, &rest.Config{}, nil
 }
 
-func (selfSubjectReviewErrorClientProvider) NewOpenShiftUserClient(string) (dynamic.Interface, *rest.Config, error) {
+func (selfSubjectReviewErrorClientProvider) NewOpenShiftUserClient(context.Context, string) (dynamic.Interface, *rest.Config, error) {
 	return fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}), &rest.Config{}, nil
 }
+
+func (selfSubjectReviewErrorClientProvider) NewServiceAccountClient(context.Context) (kubernetes.Interface, *rest.Config, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package certs serves the server's TLS certificate, reloading it when it is rotated on disk.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Watcher serves a TLS certificate and key loaded from files, reloading them when either file changes.
+// Files are polled rather than watched for events, as certificates mounted from secrets are updated by
+// atomically replacing a symlink, which is not reliably reported by file system notifications.
+type Watcher struct {
+	certFile string
+	keyFile  string
+
+	mutex    sync.RWMutex
+	cert     *tls.Certificate
+	certStat fileStat
+	keyStat  fileStat
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// fileStat identifies a version of a file
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher loads the certificate and key from certFile and keyFile, returning an error if they cannot
+// be loaded.
+func NewWatcher(certFile, keyFile string) (*Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		certFile: certFile,
+		keyFile:  keyFile,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if _, err := w.reloadIfChanged(); err != nil {
+		cancel()
+		return nil, err
+	}
+	return w, nil
+}
+
+// GetCertificate returns the current certificate. It is intended to be used as tls.Config.GetCertificate.
+func (w *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.cert, nil
+}
+
+// Start checks the certificate and key files for changes every pollPeriod until Stop is called. If
+// reloading fails (e.g. because only one of the files has been updated so far), the current certificate
+// continues to be served and reloading is retried on the next check.
+func (w *Watcher) Start(pollPeriod time.Duration) {
+	go func() {
+		ticker := time.NewTicker(pollPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reloaded, err := w.reloadIfChanged()
+				if err != nil {
+					logrus.Errorf("Failed to reload TLS certificate; continuing to use current certificate: %s", err)
+					metrics.CertificateReloaded(err)
+				} else if reloaded {
+					logrus.Infof("Reloaded TLS certificate from %s", w.certFile)
+					metrics.CertificateReloaded(nil)
+				}
+			case <-w.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking for changes to the certificate. The current certificate continues to be served.
+func (w *Watcher) Stop() {
+	w.cancel()
+}
+
+// reloadIfChanged loads the certificate and key if either file has changed since they were last loaded,
+// returning true if the certificate was reloaded.
+func (w *Watcher) reloadIfChanged() (bool, error) {
+	certStat, err := statFile(w.certFile)
+	if err != nil {
+		return false, err
+	}
+	keyStat, err := statFile(w.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	w.mutex.RLock()
+	changed := w.cert == nil || certStat != w.certStat || keyStat != w.keyStat
+	w.mutex.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.cert = &cert
+	w.certStat = certStat
+	w.keyStat = keyStat
+	return true, nil
+}
+
+func statFile(path string) (fileStat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStat{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return fileStat{modTime: info.ModTime(), size: info.Size()}, nil
+}
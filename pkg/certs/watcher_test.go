@@ -0,0 +1,132 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherReloadsCertificate(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, "first", time.Now())
+
+	watcher, err := NewWatcher(certFile, keyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "first", servedCommonName(t, watcher))
+
+	reloaded, err := watcher.reloadIfChanged()
+	assert.NoError(t, err)
+	assert.False(t, reloaded, "Should not reload if files have not changed")
+
+	writeKeyPair(t, certFile, keyFile, "second", time.Now().Add(time.Minute))
+	reloaded, err = watcher.reloadIfChanged()
+	assert.NoError(t, err)
+	assert.True(t, reloaded, "Should reload when files change")
+	assert.Equal(t, "second", servedCommonName(t, watcher))
+
+	// Simulate a rotation where only the certificate has been updated so far
+	assert.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0600))
+	assert.NoError(t, os.Chtimes(certFile, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+	_, err = watcher.reloadIfChanged()
+	assert.Error(t, err)
+	assert.Equal(t, "second", servedCommonName(t, watcher), "Should continue to serve current certificate if reload fails")
+}
+
+func TestWatcherPollsForChanges(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, "first", time.Now())
+
+	watcher, err := NewWatcher(certFile, keyFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	watcher.Start(5 * time.Millisecond)
+	defer watcher.Stop()
+
+	writeKeyPair(t, certFile, keyFile, "second", time.Now().Add(time.Minute))
+	assert.Eventually(t, func() bool {
+		return servedCommonName(t, watcher) == "second"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestNewWatcherRequiresCertificate(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewWatcher(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	assert.Error(t, err)
+	assert.Regexp(t, "failed to read .*tls.crt", err.Error())
+}
+
+func servedCommonName(t *testing.T, watcher *Watcher) string {
+	cert, err := watcher.GetCertificate(nil)
+	if !assert.NoError(t, err) || !assert.NotNil(t, cert) {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+// writeKeyPair writes a self-signed certificate for commonName and its key, setting the files' modification
+// time to modTime so that changes are detected regardless of file system timestamp granularity.
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		certFile: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyFile:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package config
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ClusterOverrides replace the defaults for the cluster in kubeconfigs created by /exec/init, which use the
+// in-cluster API server address and the service account CA bundle. Empty fields are not overridden.
+type ClusterOverrides struct {
+	// Server is the URL of the API server
+	Server string
+	// CertificateAuthorityData is a base64-encoded PEM bundle of CA certificates used to verify the API server
+	CertificateAuthorityData string
+	// TLSServerName is the server name used to verify the API server's certificate, if it differs from the
+	// host in Server
+	TLSServerName string
+	// ProxyURL is the URL of the proxy used for requests to the API server
+	ProxyURL string
+}
+
+// Override returns a copy of o in which the non-empty fields of other replace those of o.
+func (o ClusterOverrides) Override(other ClusterOverrides) ClusterOverrides {
+	if other.Server != "" {
+		o.Server = other.Server
+	}
+	if other.CertificateAuthorityData != "" {
+		o.CertificateAuthorityData = other.CertificateAuthorityData
+	}
+	if other.TLSServerName != "" {
+		o.TLSServerName = other.TLSServerName
+	}
+	if other.ProxyURL != "" {
+		o.ProxyURL = other.ProxyURL
+	}
+	return o
+}
+
+// Validate checks that the non-empty fields of o can be used in a kubeconfig.
+func (o ClusterOverrides) Validate() error {
+	if o.Server != "" {
+		if err := checkURL(o.Server, "https"); err != nil {
+			return fmt.Errorf("invalid server: %s", err)
+		}
+	}
+	if o.CertificateAuthorityData != "" {
+		pem, err := base64.StdEncoding.DecodeString(o.CertificateAuthorityData)
+		if err != nil {
+			return fmt.Errorf("invalid certificate authority data: %s", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("invalid certificate authority data: no PEM-encoded certificates found")
+		}
+	}
+	if o.TLSServerName != "" && net.ParseIP(o.TLSServerName) == nil {
+		if errs := validation.IsDNS1123Subdomain(o.TLSServerName); len(errs) > 0 {
+			return fmt.Errorf("invalid TLS server name '%s': %s", o.TLSServerName, strings.Join(errs, ", "))
+		}
+	}
+	if o.ProxyURL != "" {
+		if err := checkURL(o.ProxyURL, "http", "https", "socks5"); err != nil {
+			return fmt.Errorf("invalid proxy URL: %s", err)
+		}
+	}
+	return nil
+}
+
+// checkURL checks that value is an absolute URL with one of schemes and without credentials.
+func checkURL(value string, schemes ...string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	schemeAllowed := false
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			schemeAllowed = true
+		}
+	}
+	if !schemeAllowed {
+		return fmt.Errorf("URL '%s' must use scheme %s", value, strings.Join(schemes, ", "))
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("URL '%s' must include a host", value)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("URL '%s' must not include credentials", value)
+	}
+	return nil
+}
+
+// readCertificateAuthorityFile returns the contents of the PEM file at path as base64-encoded certificate
+// authority data.
+func readCertificateAuthorityFile(path string) (string, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(pem), nil
+}
@@ -11,9 +11,15 @@
 package config
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -32,10 +38,17 @@ var (
 	// URL to listen on (default :4444)
 	URL string
 
-	// AuthenticatedUserID OpenShift UID of user authorized to access DevWorksapce. Required; may be specified as empty
-	// string for users that do not have a UID (e.g. kubeadmin)
+	// AuthenticatedUserID OpenShift UID of user authorized to access DevWorksapce. Required in the allow-list
+	// authorization mode; may be specified as empty string for users that do not have a UID (e.g. kubeadmin)
 	AuthenticatedUserID string
 
+	// AuthorizationMode determines how users are authorized to access the terminal. Default allow-list
+	AuthorizationMode string
+
+	// AuthorizedPrincipals are additional users and groups allowed to access the terminal, with either full or
+	// read-only access. The user identified by AuthenticatedUserID always has full access. Default empty
+	AuthorizedPrincipals []Principal
+
 	// IdleTimeout is a inactivity period after which workspace should be stopped
 	// Default -1, which mean - does not stop
 	IdleTimeout time.Duration
@@ -48,11 +61,128 @@ var (
 	// Default value is controller.devfile.io/devworkspace_id=${DEVWORKSPACE_ID}
 	PodSelector string
 
+	// ScrollbackBytes is the maximum amount of terminal output retained per session for replay when a client
+	// reattaches. Default 256 KiB; 0 disables scrollback
+	ScrollbackBytes int
+
+	// RecordingDir is a directory in which terminal sessions are recorded in asciicast v2 format.
+	// Default empty, which disables recording
+	RecordingDir string
+
+	// CredentialMode determines how the user's credentials are provided in the kubeconfig created by /exec/init.
+	// Default token
+	CredentialMode string
+
+	// CredentialPluginURL is the loopback address on which the user's token is served to the exec credential
+	// plugin when CredentialMode is exec-plugin. Default 127.0.0.1:4445
+	CredentialPluginURL string
+
+	// KubeConfigCluster overrides the cluster in kubeconfigs created by /exec/init. Default empty, which uses
+	// the in-cluster API server address and the service account CA bundle
+	KubeConfigCluster ClusterOverrides
+
+	// CredentialInjectors is the ordered list of injectors that write the user's credentials into the container
+	// in /exec/init. Default kubeconfig
+	CredentialInjectors []string
+
+	// RegistryAuthHost is the registry for which credentials are written by the registry-auth injector. Default
+	// the internal OpenShift image registry, image-registry.openshift-image-registry.svc:5000
+	RegistryAuthHost string
+
+	// TokenSources is the ordered list of places in a request from which the user's token is read. Default
+	// the X-Access-Token and X-Forwarded-Access-Token headers
+	TokenSources []TokenSource
+
+	// AuthStrategies is the ordered list of strategies used to resolve the user that owns a token. Strategies
+	// are tried in order until one succeeds. Default openshift-user,self-subject-review
+	AuthStrategies []string
+
+	// AuthCacheTTL is how long the result of authenticating a token is cached. Default 1 minute; 0 disables caching
+	AuthCacheTTL time.Duration
+
+	// AuthCacheNegativeTTL is how long a failure to authenticate a token is cached. Default 10 seconds; 0 disables
+	// caching failures
+	AuthCacheNegativeTTL time.Duration
+
+	// AuthCacheSize is the maximum number of tokens for which authentication results are cached. Default 256
+	AuthCacheSize int
+
+	// TLSCertFile is the path to the server's TLS certificate. The certificate is reloaded when the file changes.
+	// Default /var/serving-cert/tls.crt
+	TLSCertFile string
+
+	// TLSKeyFile is the path to the private key for TLSCertFile. Default /var/serving-cert/tls.key
+	TLSKeyFile string
+
+	// TLSMinVersion is the minimum TLS version accepted by the server. Default TLS 1.2
+	TLSMinVersion uint16
+
+	// TLSCipherSuites is the list of cipher suites used for TLS 1.2 connections. Default nil, which uses
+	// Go's default cipher suites
+	TLSCipherSuites []uint16
+
 	// UseTLS (deprecated) kept for compatibility but if specified must have 'true' value
 	UseTLS bool
 
 	// UseBearerToken (deprecated) kept for compatibility but if specified must have 'true' value
 	UseBearerToken bool
+
+	// Unparsed values of flags for AuthorizedPrincipals, TokenSources, AuthStrategies, TLSMinVersion,
+	// TLSCipherSuites, KubeConfigCluster.CertificateAuthorityData and CredentialInjectors
+	authorizedPrincipalsFlag string
+	tokenSourcesFlag         string
+	authStrategiesFlag       string
+	tlsMinVersionFlag        string
+	tlsCipherSuitesFlag      string
+	kubeConfigCAFileFlag     string
+	credentialInjectorsFlag  string
+)
+
+// Supported values for AuthStrategies
+const (
+	// AuthStrategyOpenShiftUser looks up the current user via the OpenShift User API (user.openshift.io)
+	AuthStrategyOpenShiftUser = "openshift-user"
+	// AuthStrategySelfSubjectReview looks up the current user via a SelfSubjectReview using the user's token
+	AuthStrategySelfSubjectReview = "self-subject-review"
+	// AuthStrategyTokenReview reviews the user's token via a TokenReview using the server's service account
+	AuthStrategyTokenReview = "token-review"
+)
+
+// allowListAuthStrategies are the default AuthStrategies. They are replaced by subjectAccessReviewAuthStrategies if
+// AuthorizationMode is subject-access-review, as the openshift-user strategy does not report the scopes of tokens
+const (
+	allowListAuthStrategies           = AuthStrategyOpenShiftUser + "," + AuthStrategySelfSubjectReview
+	subjectAccessReviewAuthStrategies = AuthStrategySelfSubjectReview
+)
+
+// Supported values for AuthorizationMode
+const (
+	// AuthorizationModeAllowList authorizes the user identified by AuthenticatedUserID and the principals in
+	// AuthorizedPrincipals
+	AuthorizationModeAllowList = "allow-list"
+	// AuthorizationModeSubjectAccessReview authorizes users that are allowed by cluster RBAC to exec into
+	// pods in the workspace namespace or to patch the DevWorkspace, checked via a SubjectAccessReview
+	AuthorizationModeSubjectAccessReview = "subject-access-review"
+)
+
+// Supported values for CredentialMode
+const (
+	// CredentialModeToken writes the user's token to the kubeconfig
+	CredentialModeToken = "token"
+	// CredentialModeExecPlugin configures an exec credential plugin in the kubeconfig that fetches the user's
+	// token from the server on CredentialPluginURL, so that the token is not written to the container
+	CredentialModeExecPlugin = "exec-plugin"
+)
+
+// Supported values for CredentialInjectors
+const (
+	// CredentialInjectorKubeConfig writes a kubeconfig for the user to $KUBECONFIG or ~/.kube/config
+	CredentialInjectorKubeConfig = "kubeconfig"
+	// CredentialInjectorOc merges a cluster, user and contexts named as `oc login` names them into the kubeconfig
+	CredentialInjectorOc = "oc"
+	// CredentialInjectorRegistryAuth writes credentials for RegistryAuthHost to the containers auth.json used by
+	// podman, buildah and skopeo
+	CredentialInjectorRegistryAuth = "registry-auth"
 )
 
 const (
@@ -64,16 +194,58 @@ const (
 	devworkspaceIDEnvVar        = "DEVWORKSPACE_ID"
 	devworkspaceNameEnvVar      = "DEVWORKSPACE_NAME"
 	devworkspaceNamespaceEnvVar = "DEVWORKSPACE_NAMESPACE"
+	scrollbackBytesEnvVar       = "SCROLLBACK_BYTES"
+	recordingDirEnvVar          = "RECORDING_DIR"
+	tlsCertFileEnvVar           = "TLS_CERT_FILE"
+	tlsKeyFileEnvVar            = "TLS_KEY_FILE"
+	tlsMinVersionEnvVar         = "TLS_MIN_VERSION"
+	tlsCipherSuitesEnvVar       = "TLS_CIPHER_SUITES"
+	authStrategiesEnvVar        = "AUTH_STRATEGIES"
+	authCacheTTLEnvVar          = "AUTH_CACHE_TTL"
+	authCacheNegativeTTLEnvVar  = "AUTH_CACHE_NEGATIVE_TTL"
+	authCacheSizeEnvVar         = "AUTH_CACHE_SIZE"
+	authorizedPrincipalsEnvVar  = "AUTHORIZED_PRINCIPALS"
+	authorizationModeEnvVar     = "AUTHORIZATION_MODE"
+	tokenSourcesEnvVar          = "TOKEN_SOURCES"
+	credentialModeEnvVar        = "CREDENTIAL_MODE"
+	credentialPluginURLEnvVar   = "CREDENTIAL_PLUGIN_URL"
+	kubeConfigServerEnvVar      = "KUBECONFIG_SERVER"
+	kubeConfigCAFileEnvVar      = "KUBECONFIG_CA_FILE"
+	kubeConfigTLSServerEnvVar   = "KUBECONFIG_TLS_SERVER_NAME"
+	kubeConfigProxyURLEnvVar    = "KUBECONFIG_PROXY_URL"
+	credentialInjectorsEnvVar   = "CREDENTIAL_INJECTORS"
+	registryAuthHostEnvVar      = "REGISTRY_AUTH_HOST"
 )
 
 var (
-	defaultURLValue            = ":4444"
-	defaultAuthenticatedUserID = "\x00" // Use null char to distinguish set vs. unset
-	defaultPodSelector         = ""
-	defaultIdleTimeout         = 5 * time.Minute
-	defaultStopRetryPeriod     = 10 * time.Second
-	defaultUseBearerToken      = true
-	defaultUseTLS              = true
+	defaultURLValue             = ":4444"
+	defaultAuthenticatedUserID  = "\x00" // Use null char to distinguish set vs. unset
+	defaultPodSelector          = ""
+	defaultAuthorizedPrincipals = ""
+	defaultAuthorizationMode    = AuthorizationModeAllowList
+	defaultTokenSources         = "header:X-Access-Token,header:X-Forwarded-Access-Token"
+	defaultCredentialMode       = CredentialModeToken
+	defaultCredentialPluginURL  = "127.0.0.1:4445"
+	defaultKubeConfigServer     = ""
+	defaultKubeConfigCAFile     = ""
+	defaultKubeConfigTLSServer  = ""
+	defaultKubeConfigProxyURL   = ""
+	defaultCredentialInjectors  = CredentialInjectorKubeConfig
+	defaultRegistryAuthHost     = "image-registry.openshift-image-registry.svc:5000"
+	defaultIdleTimeout          = 5 * time.Minute
+	defaultStopRetryPeriod      = 10 * time.Second
+	defaultScrollbackBytes      = 256 << 10
+	defaultRecordingDir         = ""
+	defaultAuthStrategies       = allowListAuthStrategies
+	defaultAuthCacheTTL         = 1 * time.Minute
+	defaultAuthCacheNegativeTTL = 10 * time.Second
+	defaultAuthCacheSize        = 256
+	defaultTLSCertFile          = "/var/serving-cert/tls.crt"
+	defaultTLSKeyFile           = "/var/serving-cert/tls.key"
+	defaultTLSMinVersion        = "VersionTLS12"
+	defaultTLSCipherSuites      = ""
+	defaultUseBearerToken       = true
+	defaultUseTLS               = true
 )
 
 func ParseConfig() error {
@@ -83,12 +255,33 @@ func ParseConfig() error {
 	setLogLevel()
 
 	flag.StringVar(&URL, "url", defaultURLValue, "Host:Port address for the Web Terminal Exec server. Default is :4444")
-	flag.StringVar(&AuthenticatedUserID, "authenticated-user-id", defaultAuthenticatedUserID, "OpenShift user's ID that should has access to API. Must be set.")
+	flag.StringVar(&AuthenticatedUserID, "authenticated-user-id", defaultAuthenticatedUserID, "OpenShift user's ID that should has access to API. Must be set when '--authorization-mode' is allow-list.")
+	flag.StringVar(&authorizedPrincipalsFlag, "authorized-principals", defaultAuthorizedPrincipals, "Comma-separated list of additional principals allowed to access the terminal, in the format '<kind>:<name>[=<access>]', where kind is one of uid, user, group and access is one of full (default), read-only. Example: user:alice,group:reviewers=read-only")
+	flag.StringVar(&AuthorizationMode, "authorization-mode", defaultAuthorizationMode, "How users are authorized to access the terminal. Possible values: allow-list (the user in '--authenticated-user-id' and the principals in '--authorized-principals'), subject-access-review (users allowed by cluster RBAC to create pods/exec on the workspace pod or to patch the DevWorkspace). Default is allow-list")
 	flag.DurationVar(&IdleTimeout, "idle-timeout", defaultIdleTimeout, "IdleTimeout is a inactivity period after which workspace should be stopped. Use '-1' to disable idle timeout. Examples: -1, 30s, 15m, 1h")
 	flag.DurationVar(&StopRetryPeriod, "stop-retry-period", defaultStopRetryPeriod, "StopRetryPeriod is a period after which workspace should be tried to stop if the previous try failed. Examples: 30s")
 	flag.BoolVar(&UseBearerToken, "use-bearer-token", defaultUseBearerToken, "Use user's bearer token when communicating with OpenShift API. Option is kept for backwards-compatibility; must be set to 'true'.")
 	flag.BoolVar(&UseTLS, "use-tls", defaultUseTLS, "Serve content via TLS. Option is kept for backwards-compatibility; must be set to 'true'")
 	flag.StringVar(&PodSelector, "pod-selector", defaultPodSelector, "Selector that is used to find workspace pod. Default value is controller.devfile.io/devworkspace_id=${DEVWORKSPACE_ID}")
+	flag.IntVar(&ScrollbackBytes, "scrollback-bytes", defaultScrollbackBytes, "Maximum number of bytes of terminal output retained per session and replayed when a client reattaches. Use '0' to disable scrollback. Default is 262144 (256 KiB)")
+	flag.StringVar(&RecordingDir, "recording-dir", defaultRecordingDir, "Directory in which terminal sessions are recorded in asciicast v2 format. Recording is disabled if empty. Default is empty")
+	flag.StringVar(&CredentialMode, "credential-mode", defaultCredentialMode, "How the user's credentials are provided in the kubeconfig created by /exec/init. Possible values: token (the user's token is written to the kubeconfig), exec-plugin (the kubeconfig uses an exec credential plugin that fetches the token from '--credential-plugin-url'). Default is token")
+	flag.StringVar(&CredentialPluginURL, "credential-plugin-url", defaultCredentialPluginURL, "Loopback Host:Port address on which the user's token is served to the exec credential plugin when '--credential-mode' is exec-plugin. Default is 127.0.0.1:4445")
+	flag.StringVar(&KubeConfigCluster.Server, "kubeconfig-server", defaultKubeConfigServer, "URL of the API server in kubeconfigs created by /exec/init, e.g. the cluster's public API URL. Default is the in-cluster address from $KUBERNETES_SERVICE_HOST and $KUBERNETES_SERVICE_PORT")
+	flag.StringVar(&kubeConfigCAFileFlag, "kubeconfig-ca-file", defaultKubeConfigCAFile, "Path to a PEM bundle of CA certificates that is inlined in kubeconfigs created by /exec/init to verify the API server. Default is to reference the service account CA bundle")
+	flag.StringVar(&KubeConfigCluster.TLSServerName, "kubeconfig-tls-server-name", defaultKubeConfigTLSServer, "Server name used to verify the API server's certificate in kubeconfigs created by /exec/init. Default is the host of the API server URL")
+	flag.StringVar(&KubeConfigCluster.ProxyURL, "kubeconfig-proxy-url", defaultKubeConfigProxyURL, "URL of an http, https or socks5 proxy used for requests to the API server in kubeconfigs created by /exec/init. Default is empty")
+	flag.StringVar(&credentialInjectorsFlag, "credential-injectors", defaultCredentialInjectors, "Comma-separated list of injectors that write the user's credentials into the container in /exec/init, run in order. Possible values: kubeconfig (a kubeconfig at $KUBECONFIG or ~/.kube/config), oc (kubeconfig entries named as 'oc login' names them, merged into the kubeconfig), registry-auth (the containers auth.json for '--registry-auth-host'). Default is kubeconfig")
+	flag.StringVar(&RegistryAuthHost, "registry-auth-host", defaultRegistryAuthHost, "Host[:Port] of the image registry for which the registry-auth injector writes the user's token. Default is image-registry.openshift-image-registry.svc:5000")
+	flag.StringVar(&tokenSourcesFlag, "token-sources", defaultTokenSources, "Comma-separated list of places in a request from which the user's token is read, in order of preference. Possible values: 'header:<name>', 'authorization' (the standard 'Authorization: Bearer <token>' header), 'cookie:<name>'. Unless the default is used, requests that contain different tokens in several of these are rejected. Default is header:X-Access-Token,header:X-Forwarded-Access-Token")
+	flag.StringVar(&authStrategiesFlag, "auth-strategies", defaultAuthStrategies, "Comma-separated list of strategies used to identify the user making a request, tried in order until one succeeds. Possible values: openshift-user, self-subject-review, token-review. Default is openshift-user,self-subject-review, or self-subject-review if '--authorization-mode' is subject-access-review, in which openshift-user is not allowed")
+	flag.DurationVar(&AuthCacheTTL, "auth-cache-ttl", defaultAuthCacheTTL, "How long the result of authenticating a token is cached. Use '0' to disable caching. Default is 1m")
+	flag.DurationVar(&AuthCacheNegativeTTL, "auth-cache-negative-ttl", defaultAuthCacheNegativeTTL, "How long a failure to authenticate a token is cached. Use '0' to disable caching failures. Default is 10s")
+	flag.IntVar(&AuthCacheSize, "auth-cache-size", defaultAuthCacheSize, "Maximum number of tokens for which authentication results are cached. Default is 256")
+	flag.StringVar(&TLSCertFile, "tls-cert-file", defaultTLSCertFile, "Path to the TLS certificate served by the server. The certificate is reloaded when the file changes. Default is /var/serving-cert/tls.crt")
+	flag.StringVar(&TLSKeyFile, "tls-key-file", defaultTLSKeyFile, "Path to the private key for the TLS certificate. Default is /var/serving-cert/tls.key")
+	flag.StringVar(&tlsMinVersionFlag, "tls-min-version", defaultTLSMinVersion, "Minimum TLS version accepted by the server. Possible values: VersionTLS12, VersionTLS13. Default is VersionTLS12")
+	flag.StringVar(&tlsCipherSuitesFlag, "tls-cipher-suites", defaultTLSCipherSuites, "Comma-separated list of cipher suites for TLS 1.2 connections, using IANA names (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256). Cipher suites for TLS 1.3 are not configurable. Default is Go's default cipher suites")
 	flag.Parse()
 
 	if err := checkConfigValid(); err != nil {
@@ -112,6 +305,16 @@ func updateDefaultsFromEnv() error {
 		logrus.Infof("Read value %s from environment variable %s", authenticatedUserID, authenticatedUserIdEnvVar)
 		defaultAuthenticatedUserID = authenticatedUserID
 	}
+	authorizedPrincipals, isFound := os.LookupEnv(authorizedPrincipalsEnvVar)
+	if isFound && len(authorizedPrincipals) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", authorizedPrincipals, authorizedPrincipalsEnvVar)
+		defaultAuthorizedPrincipals = authorizedPrincipals
+	}
+	authorizationMode, isFound := os.LookupEnv(authorizationModeEnvVar)
+	if isFound && len(authorizationMode) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", authorizationMode, authorizationModeEnvVar)
+		defaultAuthorizationMode = authorizationMode
+	}
 	podSelector, isFound := os.LookupEnv(podSelectorEnvVar)
 	if isFound && len(podSelector) > 0 {
 		logrus.Infof("Read value %s from environment variable %s", podSelector, podSelectorEnvVar)
@@ -122,6 +325,117 @@ func updateDefaultsFromEnv() error {
 			defaultPodSelector = fmt.Sprintf("controller.devfile.io/devworkspace_id=%s", workspaceID)
 		}
 	}
+	scrollbackBytes, isFound := os.LookupEnv(scrollbackBytesEnvVar)
+	if isFound && len(scrollbackBytes) > 0 {
+		parsed, err := strconv.Atoi(scrollbackBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse environment variable %s: %s", scrollbackBytesEnvVar, err)
+		}
+		logrus.Infof("Read value %s from environment variable %s", scrollbackBytes, scrollbackBytesEnvVar)
+		defaultScrollbackBytes = parsed
+	}
+	recordingDir, isFound := os.LookupEnv(recordingDirEnvVar)
+	if isFound && len(recordingDir) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", recordingDir, recordingDirEnvVar)
+		defaultRecordingDir = recordingDir
+	}
+	tokenSources, isFound := os.LookupEnv(tokenSourcesEnvVar)
+	if isFound && len(tokenSources) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", tokenSources, tokenSourcesEnvVar)
+		defaultTokenSources = tokenSources
+	}
+	credentialMode, isFound := os.LookupEnv(credentialModeEnvVar)
+	if isFound && len(credentialMode) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", credentialMode, credentialModeEnvVar)
+		defaultCredentialMode = credentialMode
+	}
+	credentialPluginURL, isFound := os.LookupEnv(credentialPluginURLEnvVar)
+	if isFound && len(credentialPluginURL) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", credentialPluginURL, credentialPluginURLEnvVar)
+		defaultCredentialPluginURL = credentialPluginURL
+	}
+	kubeConfigServer, isFound := os.LookupEnv(kubeConfigServerEnvVar)
+	if isFound && len(kubeConfigServer) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", kubeConfigServer, kubeConfigServerEnvVar)
+		defaultKubeConfigServer = kubeConfigServer
+	}
+	kubeConfigCAFile, isFound := os.LookupEnv(kubeConfigCAFileEnvVar)
+	if isFound && len(kubeConfigCAFile) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", kubeConfigCAFile, kubeConfigCAFileEnvVar)
+		defaultKubeConfigCAFile = kubeConfigCAFile
+	}
+	kubeConfigTLSServer, isFound := os.LookupEnv(kubeConfigTLSServerEnvVar)
+	if isFound && len(kubeConfigTLSServer) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", kubeConfigTLSServer, kubeConfigTLSServerEnvVar)
+		defaultKubeConfigTLSServer = kubeConfigTLSServer
+	}
+	kubeConfigProxyURL, isFound := os.LookupEnv(kubeConfigProxyURLEnvVar)
+	if isFound && len(kubeConfigProxyURL) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", kubeConfigProxyURL, kubeConfigProxyURLEnvVar)
+		defaultKubeConfigProxyURL = kubeConfigProxyURL
+	}
+	credentialInjectors, isFound := os.LookupEnv(credentialInjectorsEnvVar)
+	if isFound && len(credentialInjectors) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", credentialInjectors, credentialInjectorsEnvVar)
+		defaultCredentialInjectors = credentialInjectors
+	}
+	registryAuthHost, isFound := os.LookupEnv(registryAuthHostEnvVar)
+	if isFound && len(registryAuthHost) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", registryAuthHost, registryAuthHostEnvVar)
+		defaultRegistryAuthHost = registryAuthHost
+	}
+	authStrategies, isFound := os.LookupEnv(authStrategiesEnvVar)
+	if isFound && len(authStrategies) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", authStrategies, authStrategiesEnvVar)
+		defaultAuthStrategies = authStrategies
+	}
+	authCacheTTL, isFound := os.LookupEnv(authCacheTTLEnvVar)
+	if isFound && len(authCacheTTL) > 0 {
+		parsed, err := time.ParseDuration(authCacheTTL)
+		if err != nil {
+			return fmt.Errorf("failed to parse environment variable %s: %s", authCacheTTLEnvVar, err)
+		}
+		logrus.Infof("Read value %s from environment variable %s", authCacheTTL, authCacheTTLEnvVar)
+		defaultAuthCacheTTL = parsed
+	}
+	authCacheNegativeTTL, isFound := os.LookupEnv(authCacheNegativeTTLEnvVar)
+	if isFound && len(authCacheNegativeTTL) > 0 {
+		parsed, err := time.ParseDuration(authCacheNegativeTTL)
+		if err != nil {
+			return fmt.Errorf("failed to parse environment variable %s: %s", authCacheNegativeTTLEnvVar, err)
+		}
+		logrus.Infof("Read value %s from environment variable %s", authCacheNegativeTTL, authCacheNegativeTTLEnvVar)
+		defaultAuthCacheNegativeTTL = parsed
+	}
+	authCacheSize, isFound := os.LookupEnv(authCacheSizeEnvVar)
+	if isFound && len(authCacheSize) > 0 {
+		parsed, err := strconv.Atoi(authCacheSize)
+		if err != nil {
+			return fmt.Errorf("failed to parse environment variable %s: %s", authCacheSizeEnvVar, err)
+		}
+		logrus.Infof("Read value %s from environment variable %s", authCacheSize, authCacheSizeEnvVar)
+		defaultAuthCacheSize = parsed
+	}
+	tlsCertFile, isFound := os.LookupEnv(tlsCertFileEnvVar)
+	if isFound && len(tlsCertFile) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", tlsCertFile, tlsCertFileEnvVar)
+		defaultTLSCertFile = tlsCertFile
+	}
+	tlsKeyFile, isFound := os.LookupEnv(tlsKeyFileEnvVar)
+	if isFound && len(tlsKeyFile) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", tlsKeyFile, tlsKeyFileEnvVar)
+		defaultTLSKeyFile = tlsKeyFile
+	}
+	tlsMinVersion, isFound := os.LookupEnv(tlsMinVersionEnvVar)
+	if isFound && len(tlsMinVersion) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", tlsMinVersion, tlsMinVersionEnvVar)
+		defaultTLSMinVersion = tlsMinVersion
+	}
+	tlsCipherSuites, isFound := os.LookupEnv(tlsCipherSuitesEnvVar)
+	if isFound && len(tlsCipherSuites) > 0 {
+		logrus.Infof("Read value %s from environment variable %s", tlsCipherSuites, tlsCipherSuitesEnvVar)
+		defaultTLSCipherSuites = tlsCipherSuites
+	}
 	DevWorkspaceName = os.Getenv(devworkspaceNameEnvVar)
 	DevWorkspaceNamespace = os.Getenv(devworkspaceNamespaceEnvVar)
 	DevWorkspaceID = os.Getenv(devworkspaceIDEnvVar)
@@ -138,8 +452,17 @@ func updateDefaultsFromEnv() error {
 }
 
 func checkConfigValid() error {
-	if AuthenticatedUserID == defaultAuthenticatedUserID {
-		return fmt.Errorf("authenticated user ID must be specified via '--authenticated-user-id'")
+	switch AuthorizationMode {
+	case AuthorizationModeAllowList:
+		if AuthenticatedUserID == defaultAuthenticatedUserID {
+			return fmt.Errorf("authenticated user ID must be specified via '--authenticated-user-id'")
+		}
+	case AuthorizationModeSubjectAccessReview:
+		if AuthenticatedUserID != defaultAuthenticatedUserID || authorizedPrincipalsFlag != "" {
+			logrus.Warnf("Flags '--authenticated-user-id' and '--authorized-principals' are ignored when '--authorization-mode' is %s", AuthorizationModeSubjectAccessReview)
+		}
+	default:
+		return fmt.Errorf("invalid value for '--authorization-mode': must be one of %s, %s", AuthorizationModeAllowList, AuthorizationModeSubjectAccessReview)
 	}
 	if !UseBearerToken {
 		logrus.Warn("Flag '--use-bearer-token' is kept for backwards compatibility and must be set to true. Ignoring configured value")
@@ -152,9 +475,178 @@ func checkConfigValid() error {
 	if IdleTimeout >= 0 && StopRetryPeriod < 0 {
 		return fmt.Errorf("invalid value for '--stop-retry-period': must be greater than zero if idling is enabled")
 	}
+	if ScrollbackBytes < 0 {
+		return fmt.Errorf("invalid value for '--scrollback-bytes': must not be negative")
+	}
+	if RecordingDir != "" {
+		info, err := os.Stat(RecordingDir)
+		if err != nil {
+			return fmt.Errorf("invalid value for '--recording-dir': %s", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid value for '--recording-dir': %s is not a directory", RecordingDir)
+		}
+	}
+	switch CredentialMode {
+	case CredentialModeToken:
+	case CredentialModeExecPlugin:
+		if err := checkLoopbackAddress(CredentialPluginURL); err != nil {
+			return fmt.Errorf("invalid value for '--credential-plugin-url': %s", err)
+		}
+	default:
+		return fmt.Errorf("invalid value for '--credential-mode': must be one of %s, %s", CredentialModeToken, CredentialModeExecPlugin)
+	}
+	KubeConfigCluster.CertificateAuthorityData = ""
+	if kubeConfigCAFileFlag != "" {
+		caData, err := readCertificateAuthorityFile(kubeConfigCAFileFlag)
+		if err != nil {
+			return fmt.Errorf("invalid value for '--kubeconfig-ca-file': %s", err)
+		}
+		KubeConfigCluster.CertificateAuthorityData = caData
+	}
+	if err := KubeConfigCluster.Validate(); err != nil {
+		return fmt.Errorf("invalid kubeconfig cluster options: %s", err)
+	}
+	injectors, err := parseCredentialInjectors(credentialInjectorsFlag)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--credential-injectors': %s", err)
+	}
+	CredentialInjectors = injectors
+	for _, injector := range CredentialInjectors {
+		if injector != CredentialInjectorRegistryAuth {
+			continue
+		}
+		if err := checkRegistryHost(RegistryAuthHost); err != nil {
+			return fmt.Errorf("invalid value for '--registry-auth-host': %s", err)
+		}
+		if CredentialMode == CredentialModeExecPlugin {
+			logrus.Warnf("The %s injector writes the user's token to the container, although '--credential-mode' is %s", CredentialInjectorRegistryAuth, CredentialModeExecPlugin)
+		}
+	}
+	principals, err := parsePrincipals(authorizedPrincipalsFlag)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--authorized-principals': %s", err)
+	}
+	AuthorizedPrincipals = principals
+	sources, err := parseTokenSources(tokenSourcesFlag)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--token-sources': %s", err)
+	}
+	TokenSources = sources
+	strategiesValue := authStrategiesFlag
+	if AuthorizationMode == AuthorizationModeSubjectAccessReview && strategiesValue == allowListAuthStrategies {
+		strategiesValue = subjectAccessReviewAuthStrategies
+	}
+	strategies, err := parseAuthStrategies(strategiesValue)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--auth-strategies': %s", err)
+	}
+	if AuthorizationMode == AuthorizationModeSubjectAccessReview && slices.Contains(strategies, AuthStrategyOpenShiftUser) {
+		return fmt.Errorf("invalid value for '--auth-strategies': strategy '%s' does not report the scopes of tokens, and cannot be used when '--authorization-mode' is %s", AuthStrategyOpenShiftUser, AuthorizationModeSubjectAccessReview)
+	}
+	AuthStrategies = strategies
+	if AuthCacheTTL < 0 {
+		return fmt.Errorf("invalid value for '--auth-cache-ttl': must not be negative")
+	}
+	if AuthCacheNegativeTTL < 0 {
+		return fmt.Errorf("invalid value for '--auth-cache-negative-ttl': must not be negative")
+	}
+	if AuthCacheSize < 0 {
+		return fmt.Errorf("invalid value for '--auth-cache-size': must not be negative")
+	}
+	if TLSCertFile == "" || TLSKeyFile == "" {
+		return fmt.Errorf("'--tls-cert-file' and '--tls-key-file' must not be empty")
+	}
+	minVersion, err := parseTLSVersion(tlsMinVersionFlag)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--tls-min-version': %s", err)
+	}
+	TLSMinVersion = minVersion
+	cipherSuites, err := parseCipherSuites(tlsCipherSuitesFlag)
+	if err != nil {
+		return fmt.Errorf("invalid value for '--tls-cipher-suites': %s", err)
+	}
+	if cipherSuites != nil && TLSMinVersion == tls.VersionTLS13 {
+		logrus.Warn("Flag '--tls-cipher-suites' has no effect when '--tls-min-version' is VersionTLS13")
+	}
+	TLSCipherSuites = cipherSuites
+	return nil
+}
+
+// checkLoopbackAddress checks that address is a host:port address on the loopback interface, so that it can only
+// be reached from within the workspace pod.
+func checkLoopbackAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if port == "" {
+		return fmt.Errorf("port must be specified")
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("host '%s' is not a loopback address", host)
+	}
+	return nil
+}
+
+// parseCredentialInjectors parses a comma-separated list of credential injectors, rejecting unknown or repeated
+// injectors.
+func parseCredentialInjectors(value string) ([]string, error) {
+	var injectors []string
+	seen := map[string]bool{}
+	for _, injector := range strings.Split(value, ",") {
+		injector = strings.TrimSpace(injector)
+		switch injector {
+		case CredentialInjectorKubeConfig, CredentialInjectorOc, CredentialInjectorRegistryAuth:
+		default:
+			return nil, fmt.Errorf("unknown injector '%s': must be one of %s, %s, %s", injector, CredentialInjectorKubeConfig, CredentialInjectorOc, CredentialInjectorRegistryAuth)
+		}
+		if seen[injector] {
+			return nil, fmt.Errorf("injector '%s' is specified more than once", injector)
+		}
+		seen[injector] = true
+		injectors = append(injectors, injector)
+	}
+	return injectors, nil
+}
+
+// checkRegistryHost checks that host is a registry host name, with an optional port, as used for the keys of
+// auth.json.
+func checkRegistryHost(host string) error {
+	parsed, err := url.Parse("//" + host)
+	if err != nil {
+		return err
+	}
+	if parsed.Host != host || parsed.Hostname() == "" {
+		return fmt.Errorf("'%s' must be a host name with an optional port", host)
+	}
 	return nil
 }
 
+// parseAuthStrategies parses a comma-separated list of authentication strategies, rejecting unknown or
+// repeated strategies.
+func parseAuthStrategies(value string) ([]string, error) {
+	var strategies []string
+	seen := map[string]bool{}
+	for _, strategy := range strings.Split(value, ",") {
+		strategy = strings.TrimSpace(strategy)
+		switch strategy {
+		case AuthStrategyOpenShiftUser, AuthStrategySelfSubjectReview, AuthStrategyTokenReview:
+		default:
+			return nil, fmt.Errorf("unknown strategy '%s': must be one of %s, %s, %s", strategy, AuthStrategyOpenShiftUser, AuthStrategySelfSubjectReview, AuthStrategyTokenReview)
+		}
+		if seen[strategy] {
+			return nil, fmt.Errorf("strategy '%s' is specified more than once", strategy)
+		}
+		seen[strategy] = true
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
 func setLogLevel() {
 	logLevel, isFound := os.LookupEnv("LOG_LEVEL")
 	if isFound && len(logLevel) > 0 {
@@ -178,10 +670,49 @@ func printConfig() {
 	logrus.Infof("==> Debug level %s", logrus.GetLevel().String())
 	logrus.Infof("==> Application url %s", URL)
 	logrus.Infof("==> Use bearer token: %t", UseBearerToken)
-	logrus.Infof("==> Authenticated user ID: %s", AuthenticatedUserID)
+	logrus.Infof("==> Authorization mode: %s", AuthorizationMode)
+	if AuthorizationMode == AuthorizationModeAllowList {
+		logrus.Infof("==> Authenticated user ID: %s", AuthenticatedUserID)
+		if authorizedPrincipalsFlag != "" {
+			logrus.Infof("==> Authorized principals: %s", authorizedPrincipalsFlag)
+		}
+	}
 	logrus.Infof("==> Pod selector: %s", PodSelector)
 	logrus.Infof("==> Idle timeout: %s", IdleTimeout)
 	logrus.Infof("==> Stop retry period: %s", StopRetryPeriod)
+	logrus.Infof("==> Scrollback bytes: %d", ScrollbackBytes)
+	logrus.Infof("==> Recording directory: %s", RecordingDir)
+	logrus.Infof("==> Token sources: %s", tokenSourcesFlag)
+	logrus.Infof("==> Credential mode: %s", CredentialMode)
+	if CredentialMode == CredentialModeExecPlugin {
+		logrus.Infof("==> Credential plugin url: %s", CredentialPluginURL)
+	}
+	if KubeConfigCluster.Server != "" {
+		logrus.Infof("==> Kubeconfig server: %s", KubeConfigCluster.Server)
+	}
+	if kubeConfigCAFileFlag != "" {
+		logrus.Infof("==> Kubeconfig CA file: %s", kubeConfigCAFileFlag)
+	}
+	if KubeConfigCluster.TLSServerName != "" {
+		logrus.Infof("==> Kubeconfig TLS server name: %s", KubeConfigCluster.TLSServerName)
+	}
+	if KubeConfigCluster.ProxyURL != "" {
+		logrus.Infof("==> Kubeconfig proxy url: %s", KubeConfigCluster.ProxyURL)
+	}
+	logrus.Infof("==> Credential injectors: %s", strings.Join(CredentialInjectors, ", "))
+	for _, injector := range CredentialInjectors {
+		if injector == CredentialInjectorRegistryAuth {
+			logrus.Infof("==> Registry auth host: %s", RegistryAuthHost)
+		}
+	}
+	logrus.Infof("==> Auth strategies: %s", strings.Join(AuthStrategies, ", "))
+	logrus.Infof("==> Auth cache TTL: %s (failures: %s), size: %d", AuthCacheTTL, AuthCacheNegativeTTL, AuthCacheSize)
+	logrus.Infof("==> TLS certificate: %s", TLSCertFile)
+	logrus.Infof("==> TLS key: %s", TLSKeyFile)
+	logrus.Infof("==> TLS minimum version: %s", tlsMinVersionFlag)
+	if tlsCipherSuitesFlag != "" {
+		logrus.Infof("==> TLS cipher suites: %s", tlsCipherSuitesFlag)
+	}
 }
 
 func ResetConfigForTest() {
@@ -190,16 +721,62 @@ func ResetConfigForTest() {
 	DevWorkspaceID = ""
 	URL = ""
 	AuthenticatedUserID = "\x00"
+	AuthorizedPrincipals = nil
+	authorizedPrincipalsFlag = ""
+	AuthorizationMode = AuthorizationModeAllowList
 	IdleTimeout = 0
 	StopRetryPeriod = 0
 	PodSelector = ""
+	ScrollbackBytes = 0
+	RecordingDir = ""
+	TokenSources = nil
+	tokenSourcesFlag = "header:X-Access-Token,header:X-Forwarded-Access-Token"
+	CredentialMode = CredentialModeToken
+	CredentialPluginURL = ""
+	KubeConfigCluster = ClusterOverrides{}
+	kubeConfigCAFileFlag = ""
+	CredentialInjectors = nil
+	credentialInjectorsFlag = CredentialInjectorKubeConfig
+	RegistryAuthHost = ""
+	AuthStrategies = nil
+	authStrategiesFlag = allowListAuthStrategies
+	AuthCacheTTL = 0
+	AuthCacheNegativeTTL = 0
+	AuthCacheSize = 0
+	TLSCertFile = "/var/serving-cert/tls.crt"
+	TLSKeyFile = "/var/serving-cert/tls.key"
+	TLSMinVersion = 0
+	TLSCipherSuites = nil
+	tlsMinVersionFlag = "VersionTLS12"
+	tlsCipherSuitesFlag = ""
 	UseTLS = false
 	UseBearerToken = false
 	defaultURLValue = ":4444"
 	defaultAuthenticatedUserID = "\x00"
 	defaultPodSelector = ""
+	defaultAuthorizedPrincipals = ""
+	defaultAuthorizationMode = AuthorizationModeAllowList
+	defaultTokenSources = "header:X-Access-Token,header:X-Forwarded-Access-Token"
+	defaultCredentialMode = CredentialModeToken
+	defaultCredentialPluginURL = "127.0.0.1:4445"
+	defaultKubeConfigServer = ""
+	defaultKubeConfigCAFile = ""
+	defaultKubeConfigTLSServer = ""
+	defaultKubeConfigProxyURL = ""
+	defaultCredentialInjectors = CredentialInjectorKubeConfig
+	defaultRegistryAuthHost = "image-registry.openshift-image-registry.svc:5000"
 	defaultIdleTimeout = 5 * time.Minute
 	defaultStopRetryPeriod = 10 * time.Second
+	defaultScrollbackBytes = 256 << 10
+	defaultRecordingDir = ""
+	defaultAuthStrategies = allowListAuthStrategies
+	defaultAuthCacheTTL = 1 * time.Minute
+	defaultAuthCacheNegativeTTL = 10 * time.Second
+	defaultAuthCacheSize = 256
+	defaultTLSCertFile = "/var/serving-cert/tls.crt"
+	defaultTLSKeyFile = "/var/serving-cert/tls.key"
+	defaultTLSMinVersion = "VersionTLS12"
+	defaultTLSCipherSuites = ""
 	defaultUseBearerToken = true
 	defaultUseTLS = true
 }
@@ -0,0 +1,78 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrincipalKind is the type of identifier used to match a Principal against a user
+type PrincipalKind string
+
+const (
+	PrincipalKindUID   PrincipalKind = "uid"
+	PrincipalKindUser  PrincipalKind = "user"
+	PrincipalKindGroup PrincipalKind = "group"
+)
+
+const (
+	accessFull     = "full"
+	accessReadOnly = "read-only"
+)
+
+// Principal is an entry in the list of principals allowed to access the terminal, in addition to
+// AuthenticatedUserID.
+type Principal struct {
+	Kind PrincipalKind
+	Name string
+	// ReadOnly restricts the principal to endpoints that do not start or modify terminal sessions
+	ReadOnly bool
+}
+
+// parsePrincipals parses a comma-separated list of principals in the format '<kind>:<name>[=<access>]',
+// where kind is one of uid, user or group, and access is either full (the default) or read-only. Names may
+// contain colons (e.g. 'group:system:authenticated').
+func parsePrincipals(value string) ([]Principal, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var principals []Principal
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		kind, name, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid principal '%s': must be in the format '<kind>:<name>[=<access>]'", entry)
+		}
+		principal := Principal{Kind: PrincipalKind(kind)}
+		switch principal.Kind {
+		case PrincipalKindUID, PrincipalKindUser, PrincipalKindGroup:
+		default:
+			return nil, fmt.Errorf("invalid principal '%s': kind must be one of uid, user, group", entry)
+		}
+		if idx := strings.LastIndex(name, "="); idx >= 0 {
+			switch access := name[idx+1:]; access {
+			case accessFull:
+			case accessReadOnly:
+				principal.ReadOnly = true
+			default:
+				return nil, fmt.Errorf("invalid principal '%s': access must be one of %s, %s", entry, accessFull, accessReadOnly)
+			}
+			name = name[:idx]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid principal '%s': name must not be empty", entry)
+		}
+		principal.Name = name
+		principals = append(principals, principal)
+	}
+	return principals, nil
+}
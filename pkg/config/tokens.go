@@ -0,0 +1,85 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TokenSourceKind is the part of a request from which a TokenSource reads the user's token
+type TokenSourceKind string
+
+const (
+	// TokenSourceHeader reads the token from a named request header
+	TokenSourceHeader TokenSourceKind = "header"
+	// TokenSourceAuthorization reads the token from the standard 'Authorization: Bearer <token>' header
+	TokenSourceAuthorization TokenSourceKind = "authorization"
+	// TokenSourceCookie reads the token from a named cookie
+	TokenSourceCookie TokenSourceKind = "cookie"
+)
+
+// TokenSource is an entry in the list of places in a request from which the user's token is read.
+type TokenSource struct {
+	Kind TokenSourceKind
+	// Name is the name of the header or cookie. Empty for TokenSourceAuthorization
+	Name string
+}
+
+func (s TokenSource) String() string {
+	switch s.Kind {
+	case TokenSourceAuthorization:
+		return "Authorization header"
+	case TokenSourceCookie:
+		return fmt.Sprintf("cookie %s", s.Name)
+	default:
+		return fmt.Sprintf("header %s", s.Name)
+	}
+}
+
+// parseTokenSources parses a comma-separated list of token sources in the format 'header:<name>',
+// 'authorization' or 'cookie:<name>', rejecting repeated sources. Header names are canonicalized.
+func parseTokenSources(value string) ([]TokenSource, error) {
+	var sources []TokenSource
+	seen := map[TokenSource]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		kind, name, _ := strings.Cut(entry, ":")
+		source := TokenSource{Kind: TokenSourceKind(kind), Name: name}
+		switch source.Kind {
+		case TokenSourceAuthorization:
+			if name != "" {
+				return nil, fmt.Errorf("invalid token source '%s': %s does not take a name", entry, TokenSourceAuthorization)
+			}
+		case TokenSourceHeader:
+			if name == "" {
+				return nil, fmt.Errorf("invalid token source '%s': header name must not be empty", entry)
+			}
+			source.Name = http.CanonicalHeaderKey(name)
+			if source.Name == "Authorization" {
+				return nil, fmt.Errorf("invalid token source '%s': use '%s' to read the Authorization header", entry, TokenSourceAuthorization)
+			}
+		case TokenSourceCookie:
+			if name == "" {
+				return nil, fmt.Errorf("invalid token source '%s': cookie name must not be empty", entry)
+			}
+		default:
+			return nil, fmt.Errorf("invalid token source '%s': must be one of 'header:<name>', '%s', 'cookie:<name>'", entry, TokenSourceAuthorization)
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("token source '%s' is specified more than once", entry)
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
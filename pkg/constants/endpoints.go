@@ -11,7 +11,17 @@
 package constants
 
 const (
-	ActivityTickEndpoint = "/activity/tick"
-	ExecInitEndpoint     = "/exec/init"
-	HealthzEndpoint      = "/healthz"
+	ActivityTickEndpoint     = "/activity/tick"
+	ExecInitEndpoint         = "/exec/init"
+	ExecConnectEndpoint      = "/exec/connect"
+	ExecRefreshEndpoint      = "/exec/refresh"
+	ExecLogoutEndpoint       = "/exec/logout"
+	HealthzEndpoint          = "/healthz"
+	MetricsEndpoint          = "/metrics"
+	SessionsEndpoint         = "/sessions"
+	SessionEndpoint          = "/sessions/{id}"
+	SessionRecordingEndpoint = "/sessions/{id}/recording"
+
+	// CredentialPluginEndpoint is served on the loopback credential plugin server, not the main server
+	CredentialPluginEndpoint = "/credential"
 )
@@ -0,0 +1,17 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package constants
+
+const (
+	RequestIDHeader = "X-Request-Id"
+	// CredentialKeyHeader holds the key required to read the user's token from the credential plugin server
+	CredentialKeyHeader = "X-Credential-Key"
+)
@@ -17,4 +17,42 @@ const (
 	MaxHeaderBytes     = 16 << 10 // 16 KiB
 	ServerReadTimeout  = 10 * time.Second
 	ServerWriteTimeout = 10 * time.Second
+
+	MaxTerminalMessageBytes = 64 << 10 // 64 KiB
+	TerminalPingPeriod      = 25 * time.Second
+	TerminalPongWait        = 60 * time.Second
+	TerminalWriteWait       = 10 * time.Second
+
+	DetachedSessionTimeout = 15 * time.Minute
+
+	// ExecRequestTimeout is the time allowed for requests to /exec/init, /exec/refresh and /exec/logout, which
+	// chain several operations below (e.g. pod lookup and commands in the workspace container). It replaces
+	// ServerWriteTimeout for these requests, which is then allowed for writing the response
+	ExecRequestTimeout = 2 * time.Minute
+
+	// Deadlines for individual operations against the Kubernetes API. These apply in addition to the
+	// request's context, which is cancelled if the client disconnects
+	PodLookupTimeout       = 10 * time.Second
+	UserLookupTimeout      = 10 * time.Second
+	AccessReviewTimeout    = 10 * time.Second
+	NamespaceLookupTimeout = 10 * time.Second
+	ExecCommandTimeout     = 30 * time.Second
+	WorkspaceStopTimeout   = 30 * time.Second
+
+	// WorkspaceCleanupTimeout is the time allowed for cleaning up the workspace, e.g. removing injected
+	// credentials, before it is stopped by inactivity
+	WorkspaceCleanupTimeout = 60 * time.Second
+
+	// ShutdownGracePeriod is the time allowed for in-flight requests to complete on shutdown, and
+	// ShutdownHookTimeout the time allowed for each component (e.g. terminal sessions) to shut down afterwards.
+	// Together with the time taken by the first hooks, which remove injected credentials and terminate sessions,
+	// these should be less than the pod's termination grace period (default 30s)
+	ShutdownGracePeriod = 10 * time.Second
+	ShutdownHookTimeout = 5 * time.Second
+
+	// MaxKubeConfigContexts is the maximum number of namespaces for which contexts are generated in a kubeconfig
+	MaxKubeConfigContexts = 100
+
+	// CertificatePollPeriod is how often the TLS certificate files are checked for changes
+	CertificatePollPeriod = 10 * time.Second
 )
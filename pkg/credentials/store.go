@@ -0,0 +1,140 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package credentials holds the user's token in memory for the exec credential plugin that is configured in the
+// kubeconfig when the server runs in the exec-plugin credential mode, so that the token is not written to the
+// container's filesystem.
+package credentials
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+const (
+	// execCredentialAPIVersion is the version of the exec credential plugin API used by the plugin
+	execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+	// Environment variables used to pass the URL of the credential endpoint and the store's key to the plugin
+	pluginURLEnvVar = "WEB_TERMINAL_CREDENTIAL_URL"
+	pluginKeyEnvVar = "WEB_TERMINAL_CREDENTIAL_KEY"
+
+	// keyBytes is the number of random bytes in the key required to read the token
+	keyBytes = 32
+)
+
+// pluginScript is run by the exec credential plugin to fetch an ExecCredential from the credential endpoint. It
+// uses curl if it is available in the container, and wget otherwise.
+var pluginScript = fmt.Sprintf(`set -e
+if command -v curl >/dev/null 2>&1; then
+	exec curl -sSf -H "%[1]s: $%[2]s" "$%[3]s"
+fi
+exec wget -qO- --header "%[1]s: $%[2]s" "$%[3]s"
+`, constants.CredentialKeyHeader, pluginKeyEnvVar, pluginURLEnvVar)
+
+var (
+	// ErrNoToken is returned by Store.ExecCredential if no token has been stored
+	ErrNoToken = errors.New("no token has been provided to the web terminal")
+	// ErrTokenExpired is returned by Store.ExecCredential if the stored token has expired
+	ErrTokenExpired = errors.New("the token provided to the web terminal has expired")
+)
+
+// Store holds the most recent token provided by the user, and serves it to the exec credential plugin. Reading
+// the token requires a randomly generated key, which is passed to the plugin in the kubeconfig, so that the token
+// can only be read by clients that use the kubeconfig.
+type Store struct {
+	mutex sync.RWMutex
+	token string
+	// url is the URL of the credential endpoint, as seen from the workspace containers
+	url string
+	key string
+	now func() time.Time
+}
+
+// NewStore returns an empty Store for the credential endpoint served on address (host:port).
+func NewStore(address string) (*Store, error) {
+	key := make([]byte, keyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate credential key: %w", err)
+	}
+	return &Store{
+		url: fmt.Sprintf("http://%s%s", address, constants.CredentialPluginEndpoint),
+		key: hex.EncodeToString(key),
+		now: time.Now,
+	}, nil
+}
+
+// SetToken replaces the stored token.
+func (s *Store) SetToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.token = token
+}
+
+// ClearToken discards the stored token, so that the exec credential plugin fails until a new token is set.
+func (s *Store) ClearToken() {
+	s.SetToken("")
+}
+
+// CheckKey returns whether key matches the key required to read the stored token.
+func (s *Store) CheckKey(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(key), []byte(s.key)) == 1
+}
+
+// ExecCredential returns the stored token as an ExecCredential, with the token's expiry if it is known. Returns
+// ErrNoToken or ErrTokenExpired if there is no usable token.
+func (s *Store) ExecCredential() (*clientauthv1.ExecCredential, error) {
+	s.mutex.RLock()
+	token := s.token
+	s.mutex.RUnlock()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	status := &clientauthv1.ExecCredentialStatus{Token: token}
+	if expiry := auth.TokenExpiry(token); expiry != nil {
+		if !expiry.After(s.now()) {
+			return nil, ErrTokenExpired
+		}
+		status.ExpirationTimestamp = &metav1.Time{Time: *expiry}
+	}
+	return &clientauthv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: execCredentialAPIVersion,
+			Kind:       "ExecCredential",
+		},
+		Status: status,
+	}, nil
+}
+
+// ExecConfig returns the exec credential plugin configuration that fetches the stored token, for use in a
+// kubeconfig in the workspace.
+func (s *Store) ExecConfig() *util.ExecConfig {
+	return &util.ExecConfig{
+		APIVersion: execCredentialAPIVersion,
+		Command:    "/bin/sh",
+		Args:       []string{"-c", pluginScript},
+		Env: []util.ExecEnvVar{
+			{Name: pluginURLEnvVar, Value: s.url},
+			{Name: pluginKeyEnvVar, Value: s.key},
+		},
+		InteractiveMode: "Never",
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package credentials
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCredential(t *testing.T) {
+	store, err := NewStore("127.0.0.1:4445")
+	if !assert.NoError(t, err) {
+		return
+	}
+	store.now = func() time.Time {
+		return time.Date(2029, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	_, err = store.ExecCredential()
+	assert.ErrorIs(t, err, ErrNoToken)
+
+	store.SetToken("sha256~test-token")
+	credential, err := store.ExecCredential()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "client.authentication.k8s.io/v1", credential.APIVersion)
+		assert.Equal(t, "ExecCredential", credential.Kind)
+		assert.Equal(t, "sha256~test-token", credential.Status.Token)
+		assert.Nil(t, credential.Status.ExpirationTimestamp, "Should not set expiry for opaque tokens")
+	}
+
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2lnbmF0dXJl"
+	}
+	token := jwt(`{"exp": 1893456000}`)
+	store.SetToken(token)
+	credential, err = store.ExecCredential()
+	if assert.NoError(t, err) {
+		assert.Equal(t, token, credential.Status.Token, "Should return most recent token")
+		if assert.NotNil(t, credential.Status.ExpirationTimestamp) {
+			assert.Equal(t, time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), credential.Status.ExpirationTimestamp.UTC())
+		}
+	}
+
+	store.SetToken(jwt(`{"exp": 1861920000}`))
+	_, err = store.ExecCredential()
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	store.SetToken(token)
+	store.ClearToken()
+	_, err = store.ExecCredential()
+	assert.ErrorIs(t, err, ErrNoToken, "Should not return cleared token")
+}
+
+func TestExecConfig(t *testing.T) {
+	store, err := NewStore("127.0.0.1:4445")
+	if !assert.NoError(t, err) {
+		return
+	}
+	execConfig := store.ExecConfig()
+	assert.Equal(t, "client.authentication.k8s.io/v1", execConfig.APIVersion)
+	assert.Equal(t, "Never", execConfig.InteractiveMode)
+	assert.Equal(t, []string{"-c", pluginScript}, execConfig.Args)
+	env := map[string]string{}
+	for _, envVar := range execConfig.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal(t, "http://127.0.0.1:4445/credential", env[pluginURLEnvVar])
+	assert.Len(t, env[pluginKeyEnvVar], 2*keyBytes)
+	assert.True(t, store.CheckKey(env[pluginKeyEnvVar]), "Should accept key from exec config")
+	assert.False(t, store.CheckKey(""), "Should reject empty key")
+	assert.False(t, store.CheckKey(env[pluginKeyEnvVar][1:]), "Should reject invalid key")
+
+	other, err := NewStore("127.0.0.1:4445")
+	if assert.NoError(t, err) {
+		assert.False(t, other.CheckKey(env[pluginKeyEnvVar]), "Should generate a new key for each store")
+	}
+}
+
+func TestPluginScript(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not available")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.CredentialPluginEndpoint || r.Header.Get(constants.CredentialKeyHeader) != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"kind": "ExecCredential"}`))
+	}))
+	defer server.Close()
+
+	run := func(key string) (string, error) {
+		store, err := NewStore(strings.TrimPrefix(server.URL, "http://"))
+		if err != nil {
+			return "", err
+		}
+		execConfig := store.ExecConfig()
+		cmd := exec.Command(execConfig.Command, execConfig.Args...)
+		cmd.Env = append(os.Environ(), pluginURLEnvVar+"="+store.url, pluginKeyEnvVar+"="+key)
+		output, err := cmd.Output()
+		return string(output), err
+	}
+	output, err := run("test-key")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"kind": "ExecCredential"}`, output)
+	_, err = run("invalid-key")
+	assert.Error(t, err, "Should fail if the server rejects the request")
+}
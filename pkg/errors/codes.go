@@ -0,0 +1,65 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package errors
+
+// ErrorCode is a stable, machine-readable identifier for the cause of an error response. Codes must not
+// be changed or reused once released, as clients rely on them to show actionable messages.
+type ErrorCode string
+
+const (
+	// CodeInternal is returned for unexpected errors that clients cannot act on
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+	// CodeNotFound is returned for requests to unknown endpoints
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeMethodNotAllowed is returned when an endpoint does not support the request method
+	CodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	// CodeInvalidRequest is returned when the request body or parameters cannot be parsed
+	CodeInvalidRequest ErrorCode = "INVALID_REQUEST"
+	// CodeRequestTooLarge is returned when the request body exceeds the maximum allowed size
+	CodeRequestTooLarge ErrorCode = "REQUEST_TOO_LARGE"
+	// CodeUnauthorized is returned when the request has no token, or the token's user is not authorized
+	// to access the web terminal
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	// CodeForbidden is returned when the user only has read-only access to the web terminal and the endpoint
+	// requires full access
+	CodeForbidden ErrorCode = "FORBIDDEN"
+	// CodeClientCreationFailed is returned when a Kubernetes API client cannot be created for the user
+	CodeClientCreationFailed ErrorCode = "CLIENT_CREATION_FAILED"
+	// CodePodNotFound is returned when no pod exists for the workspace
+	CodePodNotFound ErrorCode = "POD_NOT_FOUND"
+	// CodePodNotRunning is returned when the workspace pod exists but is not running
+	CodePodNotRunning ErrorCode = "POD_NOT_RUNNING"
+	// CodePodLookupFailed is returned when the workspace pod cannot be determined for other reasons
+	CodePodLookupFailed ErrorCode = "POD_LOOKUP_FAILED"
+	// CodeContainerNotFound is returned when the requested container does not exist in the workspace pod
+	CodeContainerNotFound ErrorCode = "CONTAINER_NOT_FOUND"
+	// CodeNoSuitableContainer is returned when no container was requested and none could be selected
+	CodeNoSuitableContainer ErrorCode = "NO_SUITABLE_CONTAINER"
+	// CodeKubeconfigWriteFailed is returned when the kubeconfig cannot be written to the container
+	CodeKubeconfigWriteFailed ErrorCode = "KUBECONFIG_WRITE_FAILED"
+	// CodeKubeconfigNotFound is returned when refreshing credentials in a container in which no kubeconfig has been
+	// created
+	CodeKubeconfigNotFound ErrorCode = "KUBECONFIG_NOT_FOUND"
+	// CodeCredentialInjectionFailed is returned when credentials other than the kubeconfig cannot be written to the
+	// container
+	CodeCredentialInjectionFailed ErrorCode = "CREDENTIAL_INJECTION_FAILED"
+	// CodeCredentialRemovalFailed is returned when credentials injected into a container cannot be removed
+	CodeCredentialRemovalFailed ErrorCode = "CREDENTIAL_REMOVAL_FAILED"
+	// CodeCredentialUnavailable is returned to the exec credential plugin when no unexpired token has been provided
+	// by the user
+	CodeCredentialUnavailable ErrorCode = "CREDENTIAL_UNAVAILABLE"
+	// CodeShellDetectionFailed is returned when the default shell of the container cannot be determined
+	CodeShellDetectionFailed ErrorCode = "SHELL_DETECTION_FAILED"
+	// CodeSessionNotFound is returned when a terminal session does not exist or has ended
+	CodeSessionNotFound ErrorCode = "SESSION_NOT_FOUND"
+	// CodeRecordingNotFound is returned when a session recording does not exist or recording is disabled
+	CodeRecordingNotFound ErrorCode = "RECORDING_NOT_FOUND"
+)
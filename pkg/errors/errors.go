@@ -14,23 +14,38 @@ import "fmt"
 
 type HTTPError struct {
 	StatusCode int
+	Code       ErrorCode
 	Message    string
+	// Details optionally provides additional information about the cause of the error, e.g. the
+	// underlying error message
+	Details string
 }
 
 func (e HTTPError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%d: %s: %s", e.StatusCode, e.Message, e.Details)
+	}
 	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
 }
 
-func NewHTTPError(statusCode int, message string) *HTTPError {
+// WithDetails sets the details of the error and returns it.
+func (e *HTTPError) WithDetails(details string) *HTTPError {
+	e.Details = details
+	return e
+}
+
+func NewHTTPError(statusCode int, code ErrorCode, message string) *HTTPError {
 	return &HTTPError{
 		StatusCode: statusCode,
+		Code:       code,
 		Message:    message,
 	}
 }
 
-func NewHTTPErrorf(statusCode int, messageFmt string, args ...interface{}) *HTTPError {
+func NewHTTPErrorf(statusCode int, code ErrorCode, messageFmt string, args ...interface{}) *HTTPError {
 	return &HTTPError{
 		StatusCode: statusCode,
+		Code:       code,
 		Message:    fmt.Sprintf(messageFmt, args...),
 	}
 }
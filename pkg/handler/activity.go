@@ -14,8 +14,7 @@ import "net/http"
 
 func (s *Router) handleActivityTick(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		w.Header().Add("Allow", http.MethodPost)
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		handleMethodNotAllowed(w, r, http.MethodPost)
 		return
 	}
 	s.ActivityManager.Tick()
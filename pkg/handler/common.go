@@ -11,51 +11,146 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/activity"
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
 	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/credentials"
 	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/injector"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/session"
 )
 
 type Router struct {
 	ActivityManager activity.ActivityManager
 	ClientProvider  operations.ClientProvider
+	SessionRegistry *session.Registry
+	// Authenticator identifies the user making a request. If nil, auth.DefaultAuthenticator is used
+	Authenticator auth.Authenticator
+	// Authorizer determines the access level of an authenticated user. If nil, auth.DefaultAuthorizer is used
+	Authorizer auth.Authorizer
+	// CredentialStore holds the user's token for the exec credential plugin. If nil, the user's token is written
+	// to the kubeconfig instead of configuring the plugin
+	CredentialStore *credentials.Store
+	// CredentialInjectors write the user's credentials into the container in /exec/init, in order. If nil,
+	// injector.Default is used, which only writes a kubeconfig
+	CredentialInjectors []injector.Injector
+	// CredentialTracker records the credentials injected into containers, so that they can be removed by
+	// /exec/logout. If nil, credentials are not tracked, and /exec/logout only discards the token in the
+	// CredentialStore
+	CredentialTracker *injector.Tracker
+	// UserCache caches the results of authenticating users. May be nil, in which case every request is
+	// authenticated against the API server
+	UserCache *auth.UserCache
 }
 
 func (s *Router) HTTPSHandler() http.Handler {
 	mux := http.NewServeMux()
 	handle := func(path string, handler http.Handler, middlewares ...middleware) {
+		requestIDMiddleware := requestIDMiddleware{}
 		loggingMiddleware := logRequestMiddleware{path}
+		tracingMiddleware := traceRequestMiddleware{path}
 		composedHandler := handler
 		// Note this will work in reverse order (i.e. the middleware specified last will be used first)
 		for _, m := range middlewares {
 			composedHandler = m.addMiddleware(composedHandler)
 		}
+		composedHandler = tracingMiddleware.addMiddleware(composedHandler)
 		composedHandler = loggingMiddleware.addMiddleware(composedHandler)
+		composedHandler = requestIDMiddleware.addMiddleware(composedHandler)
 		mux.Handle(path, composedHandler)
 	}
 	handleFunc := func(path string, handler http.HandlerFunc, middlewares ...middleware) {
 		handle(path, handler, middlewares...)
 	}
 
+	authenticator := s.Authenticator
+	if authenticator == nil {
+		authenticator = auth.DefaultAuthenticator(s.ClientProvider)
+	}
+	authorizer := s.Authorizer
+	if authorizer == nil {
+		authorizer = auth.DefaultAuthorizer()
+	}
+	// Endpoints that start, modify or interact with terminal sessions, or expose their output, require full access
+	readOnlyAccess := &authMiddleware{authenticator: authenticator, authorizer: authorizer, cache: s.UserCache, requiredAccess: auth.AccessReadOnly}
+	fullAccess := &authMiddleware{authenticator: authenticator, authorizer: authorizer, cache: s.UserCache, requiredAccess: auth.AccessFull}
+	// Applied after authentication, so that the timeout also covers looking up the user
+	execTimeout := &requestTimeoutMiddleware{timeout: constants.ExecRequestTimeout}
+
 	// Serve /activity/tick endpoint
-	handleFunc(constants.ActivityTickEndpoint, s.handleActivityTick, &authMiddleware{s.ClientProvider})
+	handleFunc(constants.ActivityTickEndpoint, s.handleActivityTick, readOnlyAccess)
 
 	// Serve /exec/init endpoint
-	handleFunc(constants.ExecInitEndpoint, s.handleExecInit, &authMiddleware{s.ClientProvider})
+	handleFunc(constants.ExecInitEndpoint, s.handleExecInit, fullAccess, execTimeout)
+
+	// Serve /exec/connect endpoint
+	handleFunc(constants.ExecConnectEndpoint, s.handleExecConnect, fullAccess)
+
+	// Serve /exec/refresh endpoint
+	handleFunc(constants.ExecRefreshEndpoint, s.handleExecRefresh, fullAccess, execTimeout)
+
+	// Serve /exec/logout endpoint
+	handleFunc(constants.ExecLogoutEndpoint, s.handleExecLogout, fullAccess, execTimeout)
+
+	// Serve /sessions endpoints
+	handleFunc(constants.SessionsEndpoint, s.handleListSessions, readOnlyAccess)
+	handleFunc(constants.SessionEndpoint, s.handleSession, fullAccess)
+	// Recordings contain the full terminal output, which may include secrets
+	handleFunc(constants.SessionRecordingEndpoint, s.handleSessionRecording, fullAccess)
 
 	// Serve /healthz endpoint
 	handleFunc(constants.HealthzEndpoint, s.handleHealthCheck)
+
+	// Serve /metrics endpoint
+	handleFunc(constants.MetricsEndpoint, s.handleMetrics)
+
+	// Serve JSON errors for unknown endpoints
+	mux.Handle("/", (&requestIDMiddleware{}).addMiddleware(http.HandlerFunc(handleNotFound)))
 	return http.Handler(mux)
 }
 
-func handleError(w http.ResponseWriter, err error) {
-	switch t := err.(type) {
-	case *errors.HTTPError:
-		http.Error(w, t.Message, t.StatusCode)
-	default:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// handleError writes err to the response as an api.ErrorResponse. Errors other than errors.HTTPError
+// are returned as internal errors.
+func handleError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode := http.StatusInternalServerError
+	response := api.ErrorResponse{
+		Code:      string(errors.CodeInternal),
+		Message:   err.Error(),
+		RequestID: logging.RequestID(r.Context()),
+	}
+	if httpErr, ok := err.(*errors.HTTPError); ok {
+		statusCode = httpErr.StatusCode
+		response.Code = string(httpErr.Code)
+		response.Message = httpErr.Message
+		response.Details = httpErr.Details
+	}
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		logging.FromContext(r.Context()).Errorf("Failed to marshal error response: %s", err)
+		http.Error(w, response.Message, statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(responseJson); err != nil {
+		logging.FromContext(r.Context()).Errorf("Failed to write error response to %s request", r.URL.Path)
 	}
 }
+
+// handleMethodNotAllowed returns an error for requests using a method that is not supported by the
+// endpoint. Supported methods are listed in the Allow header.
+func handleMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed string) {
+	w.Header().Add("Allow", allowed)
+	handleError(w, r, errors.NewHTTPErrorf(http.StatusMethodNotAllowed, errors.CodeMethodNotAllowed, "method %s is not allowed", r.Method))
+}
+
+func handleNotFound(w http.ResponseWriter, r *http.Request) {
+	handleError(w, r, errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeNotFound, "endpoint %s not found", r.URL.Path))
+}
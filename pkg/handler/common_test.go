@@ -12,28 +12,49 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/redhat-developer/web-terminal-exec/pkg/activity"
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
 	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/credentials"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/injector"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
 	optest "github.com/redhat-developer/web-terminal-exec/pkg/operations/test"
+	"github.com/redhat-developer/web-terminal-exec/pkg/session"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
+	"github.com/redhat-developer/web-terminal-exec/pkg/util"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
 	"sigs.k8s.io/yaml"
 )
 
 const (
-	testUserToken = "test-user-token"
+	testUserToken       = "test-user-token"
+	testScrollbackBytes = 1024
 )
 
 func setConfigForTest() {
@@ -61,6 +82,9 @@ func TestRouter(t *testing.T) {
 		headers     http.Header
 		respCode    int
 		respBody    string
+		errCode     errors.ErrorCode
+		errMessage  string
+		errDetails  string
 	}{
 		{
 			name:     "test /healthz returns 200",
@@ -78,12 +102,13 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Forwarded-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
 				},
 			},
@@ -93,12 +118,13 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "multi-container-pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Forwarded-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
 				},
 			},
@@ -108,12 +134,13 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "generic-pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "test-user-defined", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "test-user-defined", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Forwarded-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
 				},
 			},
@@ -123,11 +150,13 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "only-exec-pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusBadRequest,
+			errCode:     errors.CodeNoSuitableContainer,
 			headers:     http.Header{"X-Forwarded-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
 				},
 			},
@@ -137,12 +166,13 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Forwarded-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
 				},
 			},
@@ -152,14 +182,15 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL":     "\n",
-						"id -u":           "1234\n",
-						"cat /etc/passwd": "user:x:1234:0:user user:/home/user:test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "\n",
+						"id -u":                        "1234\n",
+						"cat /etc/passwd":              "user:x:1234:0:user user:/home/user:test_shellcommand\n",
 					},
 				},
 			},
@@ -169,14 +200,15 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"container": "web-terminal-exec", "kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusOK,
-			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-exec", "cmd": ["test_shellcommand"]}`,
+			respBody:    `{"pod": "test-terminal-pod", "container": "web-terminal-exec", "cmd": ["test_shellcommand"], "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`,
 			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL":     "\n",
-						"id -u":           "1234\n",
-						"cat /etc/passwd": "user:x:1234:0:user user:/home/user:test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "\n",
+						"id -u":                        "1234\n",
+						"cat /etc/passwd":              "user:x:1234:0:user user:/home/user:test_shellcommand\n",
 					},
 				},
 			},
@@ -186,6 +218,22 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"container": "not-exist", "kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusBadRequest,
+			errCode:     errors.CodeContainerNotFound,
+			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
+		},
+		{
+			name:     "test workspace pod not found",
+			req:      httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
+			respCode: http.StatusInternalServerError,
+			errCode:  errors.CodePodNotFound,
+			headers:  http.Header{"X-Access-Token": []string{testUserToken}},
+		},
+		{
+			name:        "test invalid request body",
+			initialObjs: loadPodFromFile(t, "pod.yaml"),
+			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": `))),
+			respCode:    http.StatusBadRequest,
+			errCode:     errors.CodeInvalidRequest,
 			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
 		},
 		{
@@ -193,13 +241,15 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusInternalServerError,
+			errCode:     errors.CodeShellDetectionFailed,
 			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL":     "\n",
-						"id -u":           "1234\n",
-						"cat /etc/passwd": "\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "\n",
+						"id -u":                        "1234\n",
+						"cat /etc/passwd":              "\n",
 					},
 				},
 			},
@@ -209,13 +259,18 @@ func TestRouter(t *testing.T) {
 			initialObjs: loadPodFromFile(t, "pod.yaml"),
 			req:         httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`))),
 			respCode:    http.StatusInternalServerError,
+			errCode:     errors.CodeKubeconfigWriteFailed,
+			errMessage:  "Failed to create kubeconfig in container 'web-terminal-tooling'",
+			errDetails:  "failed to write /home/user/.kube/config: error executing command in container: bad input in test",
 			headers:     http.Header{"X-Access-Token": []string{testUserToken}},
 			spdy: optest.FakeSPDYExecutorProvider{
 				FakeSPDYExecutor: optest.FakeSPDYExecutor{
 					ResponseOutputs: map[string]string{
-						"echo $SHELL": "test_shellcommand\n",
+						injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+						"echo $SHELL":                  "test_shellcommand\n",
 					},
-					ErrInputs: []string{"$KUBECONFIG"},
+					// Only matches the kubeconfig written over stdin, so that the path lookup succeeds
+					ErrInputs: []string{"apiVersion: v1"},
 				},
 			},
 		},
@@ -225,6 +280,7 @@ func TestRouter(t *testing.T) {
 		t.Run(fmt.Sprintf("%s (%s %s)", tt.name, tt.req.Method, tt.req.URL.Path), func(t *testing.T) {
 			router := Router{
 				ActivityManager: noOpActivityManager,
+				SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
 				ClientProvider: optest.FakeClientProvider{
 					InitialObjs: tt.initialObjs,
 					UserToken:   testUserToken,
@@ -256,6 +312,19 @@ func TestRouter(t *testing.T) {
 			if tt.respBody != "" {
 				assert.Equal(t, strings.ReplaceAll(tt.respBody, " ", ""), actualBody)
 			}
+			if tt.errCode != "" {
+				errResponse := api.ErrorResponse{}
+				if assert.NoError(t, json.Unmarshal(actualBodyBytes, &errResponse), "Error response should be JSON") {
+					assert.Equal(t, string(tt.errCode), errResponse.Code)
+					assert.NotEmpty(t, errResponse.Message)
+					if tt.errMessage != "" {
+						assert.Equal(t, tt.errMessage, errResponse.Message)
+					}
+					if tt.errDetails != "" {
+						assert.Equal(t, tt.errDetails, errResponse.Details)
+					}
+				}
+			}
 		})
 	}
 }
@@ -267,6 +336,7 @@ func TestAuthorizedAccess(t *testing.T) {
 	assert.NoError(t, err)
 	router := Router{
 		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
 		ClientProvider: optest.FakeClientProvider{
 			UserToken: testUserToken,
 		},
@@ -322,6 +392,52 @@ func TestAuthorizedAccess(t *testing.T) {
 	}
 }
 
+func TestReadOnlyAccess(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	const readOnlyToken = "read-only-user-token"
+	config.AuthorizedPrincipals = []config.Principal{{Kind: config.PrincipalKindUID, Name: readOnlyToken, ReadOnly: true}}
+	defer func() { config.AuthorizedPrincipals = nil }()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			UserToken: testUserToken,
+		},
+	}
+	handler := router.HTTPSHandler()
+
+	tests := []struct {
+		method     string
+		path       string
+		statusCode int
+	}{
+		{method: "POST", path: "/activity/tick", statusCode: http.StatusNoContent},
+		{method: "GET", path: "/sessions", statusCode: http.StatusOK},
+		{method: "POST", path: "/exec/init", statusCode: http.StatusForbidden},
+		{method: "GET", path: "/exec/connect", statusCode: http.StatusForbidden},
+		{method: "DELETE", path: "/sessions/test-session", statusCode: http.StatusForbidden},
+		{method: "GET", path: "/sessions/test-session/recording", statusCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.method, tt.path), func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Add("X-Access-Token", readOnlyToken)
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.statusCode, recorder.Code)
+			if tt.statusCode == http.StatusForbidden {
+				var errResp api.ErrorResponse
+				assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp))
+				assert.Equal(t, "FORBIDDEN", errResp.Code)
+				assert.Equal(t, "full access is required to use this endpoint", errResp.Message)
+			}
+		})
+	}
+}
+
 func TestRouterEndpoints(t *testing.T) {
 	logrus.SetOutput(io.Discard)
 
@@ -329,6 +445,7 @@ func TestRouterEndpoints(t *testing.T) {
 	assert.NoError(t, err)
 	router := Router{
 		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
 		ClientProvider: optest.FakeClientProvider{
 			UserToken: testUserToken,
 		},
@@ -354,6 +471,11 @@ func TestRouterEndpoints(t *testing.T) {
 			supportedMethods: []string{"GET"},
 			respCode:         http.StatusMethodNotAllowed,
 		},
+		{
+			endpoint:         "/metrics",
+			supportedMethods: []string{"GET"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
 		{
 			endpoint:         "/activity/tick",
 			supportedMethods: []string{"POST"},
@@ -364,6 +486,31 @@ func TestRouterEndpoints(t *testing.T) {
 			supportedMethods: []string{"POST"},
 			respCode:         http.StatusMethodNotAllowed,
 		},
+		{
+			endpoint:         "/exec/logout",
+			supportedMethods: []string{"POST"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
+		{
+			endpoint:         "/exec/connect",
+			supportedMethods: []string{"GET"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
+		{
+			endpoint:         "/sessions",
+			supportedMethods: []string{"GET"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
+		{
+			endpoint:         "/sessions/test-id",
+			supportedMethods: []string{"DELETE"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
+		{
+			endpoint:         "/sessions/test-id/recording",
+			supportedMethods: []string{"GET"},
+			respCode:         http.StatusMethodNotAllowed,
+		},
 		{
 			endpoint:         "/activity/",
 			supportedMethods: []string{},
@@ -393,11 +540,849 @@ func TestRouterEndpoints(t *testing.T) {
 				req.Header.Add("X-Access-Token", testUserToken)
 				handler.ServeHTTP(recorder, req)
 				assert.Equal(t, tt.respCode, recorder.Code, "Wrong code returned")
+				assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"), "Error response should be JSON")
+			}
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			UserToken: testUserToken,
+		},
+	}
+	req := httptest.NewRequest("DELETE", "/sessions/not-exist", nil)
+	req.Header.Add("X-Access-Token", testUserToken)
+	req.Header.Add("X-Request-Id", "test-request-id")
+	recorder := httptest.NewRecorder()
+	router.HTTPSHandler().ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"code": "SESSION_NOT_FOUND",
+		"message": "session 'not-exist' not found",
+		"requestId": "test-request-id"
+	}`, recorder.Body.String())
+}
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logrus.SetOutput(&logs)
+	defer logrus.SetOutput(io.Discard)
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			UserToken: testUserToken,
+		},
+	}
+	handler := router.HTTPSHandler()
+
+	tests := []struct {
+		name      string
+		requestID string
+		reuseID   bool
+	}{
+		{
+			name:      "Uses request ID from header",
+			requestID: "test-request-id",
+			reuseID:   true,
+		},
+		{
+			name:      "Generates request ID if not provided",
+			requestID: "",
+		},
+		{
+			name:      "Replaces invalid request ID",
+			requestID: "invalid request id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest("POST", "/activity/tick", nil)
+			req.Header.Add("X-Access-Token", "bad token")
+			if tt.requestID != "" {
+				req.Header.Add("X-Request-Id", tt.requestID)
 			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			requestID := recorder.Header().Get("X-Request-Id")
+			if tt.reuseID {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				assert.Regexp(t, "^[0-9a-f]{32}$", requestID)
+			}
+			errResponse := api.ErrorResponse{}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResponse))
+			assert.Equal(t, requestID, errResponse.RequestID, "Error response should include request ID")
+			assert.Contains(t, logs.String(), "requestId="+requestID, "Logs should include request ID")
 		})
 	}
 }
 
+func TestRequestTimeout(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	var deadline time.Time
+	handler := (&requestTimeoutMiddleware{timeout: time.Second}).addMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+		// Outlast the server's write timeout
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL)
+	if !assert.NoError(t, err, "Should extend write deadline for slow requests") {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.WithinDuration(t, start.Add(time.Second), deadline, 100*time.Millisecond, "Should cancel requests after timeout")
+}
+
+func TestExecConnect(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "8443")
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			InitialObjs: loadPodFromFile(t, "pod.yaml"),
+			UserToken:   testUserToken,
+		},
+	}
+	server := httptest.NewServer(router.HTTPSHandler())
+	defer server.Close()
+
+	shellExecutor := optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{
+				"echo $SHELL": "test_shellcommand\n",
+			},
+		},
+	}
+	terminalExecutor := optest.EchoSPDYExecutorProvider{}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+		if url.Query().Get("tty") == "true" {
+			return terminalExecutor.NewEchoSPDYExecutor(config, method, url)
+		}
+		return shellExecutor.NewFakeSPDYExecutor(config, method, url)
+	}
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	connectURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/exec/connect"
+	_, resp, err := websocket.DefaultDialer.Dial(connectURL, nil)
+	if assert.Error(t, err, "Should not connect without access token") {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	headers := http.Header{"X-Access-Token": []string{testUserToken}}
+	conn, _, err := websocket.DefaultDialer.Dial(connectURL, headers)
+	if !assert.NoError(t, err, "Should connect to terminal") {
+		return
+	}
+	defer conn.Close()
+	sessionMessage := api.TerminalMessage{}
+	if !assert.NoError(t, conn.ReadJSON(&sessionMessage), "Should receive session message") {
+		return
+	}
+	assert.Equal(t, api.TerminalMessageSession, sessionMessage.Type)
+	assert.NotEmpty(t, sessionMessage.SessionID)
+
+	assert.NoError(t, conn.WriteJSON(api.TerminalMessage{Type: api.TerminalMessageResize, Cols: 80, Rows: 24}))
+	assert.NoError(t, conn.WriteJSON(api.TerminalMessage{Type: api.TerminalMessageInput, Data: "ls -l\n"}))
+	messageType, output, err := conn.ReadMessage()
+	if assert.NoError(t, err, "Should read terminal output") {
+		assert.Equal(t, websocket.BinaryMessage, messageType)
+		assert.Equal(t, "ls -l\n", string(output))
+	}
+	assert.Eventually(t, func() bool {
+		return len(terminalExecutor.GetResizes()) == 1
+	}, time.Second, 10*time.Millisecond, "Should forward resize events")
+	assert.Equal(t, []remotecommand.TerminalSize{{Width: 80, Height: 24}}, terminalExecutor.GetResizes())
+
+	// Session should keep running after client disconnects
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		sessions := router.SessionRegistry.List()
+		return len(sessions) == 1 && !sessions[0].Info().Attached
+	}, time.Second, 10*time.Millisecond, "Session should be detached")
+
+	listReq := httptest.NewRequest("GET", "/sessions", nil)
+	listReq.Header = headers
+	listRecorder := httptest.NewRecorder()
+	router.HTTPSHandler().ServeHTTP(listRecorder, listReq)
+	assert.Equal(t, http.StatusOK, listRecorder.Code)
+	assert.Contains(t, listRecorder.Body.String(), sessionMessage.SessionID)
+
+	_, resp, err = websocket.DefaultDialer.Dial(connectURL+"?session=not-exist", headers)
+	if assert.Error(t, err, "Should not attach to session that does not exist") {
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	// Reattaching replays scrollback
+	reattachConn, _, err := websocket.DefaultDialer.Dial(connectURL+"?session="+sessionMessage.SessionID, headers)
+	if !assert.NoError(t, err, "Should reattach to session") {
+		return
+	}
+	defer reattachConn.Close()
+	reattachMessage := api.TerminalMessage{}
+	assert.NoError(t, reattachConn.ReadJSON(&reattachMessage))
+	assert.Equal(t, sessionMessage.SessionID, reattachMessage.SessionID)
+	_, output, err = reattachConn.ReadMessage()
+	if assert.NoError(t, err, "Should read scrollback") {
+		assert.Equal(t, "ls -l\n", string(output))
+	}
+
+	// Sessions started by other users cannot be reattached
+	client, restconfig, err := router.ClientProvider.NewClientWithToken(context.Background(), "other-user-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	otherSession, err := router.SessionRegistry.Start(context.Background(), client, restconfig, "test-terminal-pod", "web-terminal-tooling", "/bin/bash", "other-user-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, resp, err = websocket.DefaultDialer.Dial(connectURL+"?session="+otherSession.ID, headers)
+	if assert.Error(t, err, "Should not attach to session started by another user") {
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+	assert.False(t, otherSession.Info().Attached)
+	otherSession.Terminate()
+	<-otherSession.Done()
+
+	deleteReq := httptest.NewRequest("DELETE", "/sessions/"+sessionMessage.SessionID, nil)
+	deleteReq.Header = headers
+	deleteRecorder := httptest.NewRecorder()
+	router.HTTPSHandler().ServeHTTP(deleteRecorder, deleteReq)
+	assert.Equal(t, http.StatusNoContent, deleteRecorder.Code)
+	_, _, err = reattachConn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), "Terminal should be closed when session is terminated")
+	assert.Eventually(t, func() bool {
+		return len(router.SessionRegistry.List()) == 0
+	}, time.Second, 10*time.Millisecond, "Session should be removed once terminated")
+}
+
+func TestExecInitTracing(t *testing.T) {
+	router := newExecTestRouter(t)
+
+	_, err := tracing.Setup(context.Background())
+	assert.NoError(t, err)
+	spanRecorder := tracetest.NewSpanRecorder()
+	oldTracerProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	defer otel.SetTracerProvider(oldTracerProvider)
+
+	spdy := newExecInitSPDY()
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = spdy.NewFakeSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	req := httptest.NewRequest("POST", "/exec/init", bytes.NewBuffer([]byte(`{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`)))
+	req.Header.Add("X-Access-Token", testUserToken)
+	req.Header.Add("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	recorder := httptest.NewRecorder()
+	router.HTTPSHandler().ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spanRecorder.Ended() {
+		spans[span.Name()] = span
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String(), "Span %s should continue propagated trace", span.Name())
+	}
+	serverSpan, ok := spans["POST /exec/init"]
+	if !assert.True(t, ok, "Should record span for request") {
+		return
+	}
+	assert.Equal(t, "b7ad6b7169203331", serverSpan.Parent().SpanID().String(), "Request span should be child of propagated span")
+	for _, name := range []string{"Authenticate", "NewClientWithToken", "GetCurrentWorkspacePod", "InjectKubeconfig", "DetectShell"} {
+		span, ok := spans[name]
+		if assert.True(t, ok, "Should record span %s", name) {
+			assert.Equal(t, serverSpan.SpanContext().SpanID(), span.Parent().SpanID(), "Span %s should be child of request span", name)
+		}
+	}
+}
+
+func TestExecInitMergesKubeconfig(t *testing.T) {
+	router := newExecTestRouter(t)
+	existing := `apiVersion: v1
+kind: Config
+clusters:
+- name: other-cluster
+  cluster:
+    server: https://other:6443
+users:
+- name: other-user
+  user:
+    token: other-token
+contexts:
+- name: other-context
+  context:
+    cluster: other-cluster
+    user: other-user
+current-context: other-context
+`
+	spdy := &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{
+				injector.ReadKubeConfigCommand: "/home/user/.kube/config\n" + existing,
+				"echo $SHELL":                  "test_shellcommand\n",
+			},
+		},
+	}
+	recorder := serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test", "namespace": "test-namespace", "merge": true}}`)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	if assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2, "Should read and write kubeconfig") {
+		assert.Equal(t, injector.ReadKubeConfigCommand, spdy.InputBuffers[0])
+		written := spdy.InputBuffers[1]
+		assert.True(t, strings.HasPrefix(written, "apiVersion: v1\n"), "Should write kubeconfig over stdin without a script")
+		assert.Contains(t, written, "token: "+testUserToken)
+		assert.Contains(t, written, "current-context: test-context")
+		assert.Contains(t, written, "name: other-context", "Should preserve existing contexts")
+		assert.Contains(t, written, "token: other-token", "Should preserve existing users")
+	}
+}
+
+func TestExecInitClusterOverrides(t *testing.T) {
+	router := newExecTestRouter(t)
+	config.KubeConfigCluster = config.ClusterOverrides{
+		Server:   "https://api.example.com:6443",
+		ProxyURL: "http://proxy:3128",
+	}
+
+	spdy := newExecInitSPDY()
+	recorder := serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test", "tlsServerName": "api-int.example.com"}}`)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	if assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2, "Should write kubeconfig") {
+		var kubeconfig util.KubeConfig
+		if assert.NoError(t, yaml.Unmarshal([]byte(spdy.InputBuffers[1]), &kubeconfig)) && assert.Len(t, kubeconfig.Clusters, 1) {
+			assert.Equal(t, util.ClusterInfo{
+				Server:               "https://api.example.com:6443",
+				CertificateAuthority: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+				TLSServerName:        "api-int.example.com",
+				ProxyURL:             "http://proxy:3128",
+			}, kubeconfig.Clusters[0].Cluster, "Should combine request and server cluster options")
+		}
+	}
+
+	spdy = &optest.FakeSPDYExecutorProvider{}
+	recorder = serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test", "server": "http://api.example.com", "certificateAuthorityData": "invalid"}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeInvalidRequest), errResp.Code)
+		assert.Contains(t, errResp.Details, "invalid server")
+	}
+	assert.Empty(t, spdy.InputBuffers, "Should not exec into container if options are invalid")
+}
+
+func TestExecInitNamespaces(t *testing.T) {
+	router := newExecTestRouter(t)
+	router.ClientProvider = optest.FakeClientProvider{
+		InitialObjs: loadPodFromFile(t, "pod.yaml"),
+		UserToken:   testUserToken,
+		Projects:    []string{"project-b", "project-a"},
+	}
+	execInit := func(spdy *optest.FakeSPDYExecutorProvider, body string) *httptest.ResponseRecorder {
+		return serveExecRequest(router, spdy, "/exec/init", body)
+	}
+	contextNamespaces := func(kubeconfig string) map[string]string {
+		var parsed util.KubeConfig
+		if !assert.NoError(t, yaml.Unmarshal([]byte(kubeconfig), &parsed)) {
+			return nil
+		}
+		assert.Equal(t, "test-context", parsed.CurrentContext)
+		namespaces := map[string]string{}
+		for _, context := range parsed.Contexts {
+			namespaces[context.Name] = context.Context.Namespace
+		}
+		return namespaces
+	}
+
+	spdy := newExecInitSPDY()
+	recorder := execInit(spdy, `{"kubeconfig": {"username": "test", "namespace": "project-a", "namespaces": ["extra"]}}`)
+	if assert.Equal(t, http.StatusOK, recorder.Code) && assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2) {
+		assert.Equal(t, map[string]string{
+			"test-context":       "project-a",
+			"test-extra-context": "extra",
+		}, contextNamespaces(spdy.InputBuffers[1]), "Should add contexts for requested namespaces")
+	}
+
+	spdy = newExecInitSPDY()
+	recorder = execInit(spdy, `{"kubeconfig": {"username": "test", "namespace": "project-a", "namespaces": ["extra"], "discoverNamespaces": true}}`)
+	if assert.Equal(t, http.StatusOK, recorder.Code) && assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2) {
+		assert.Equal(t, map[string]string{
+			"test-context":           "project-a",
+			"test-extra-context":     "extra",
+			"test-project-b-context": "project-b",
+		}, contextNamespaces(spdy.InputBuffers[1]), "Should add contexts for discovered namespaces")
+	}
+
+	var projects []string
+	for i := constants.MaxKubeConfigContexts + 10; i > 0; i-- {
+		projects = append(projects, fmt.Sprintf("project-%03d", i))
+	}
+	router.ClientProvider = optest.FakeClientProvider{
+		InitialObjs: loadPodFromFile(t, "pod.yaml"),
+		UserToken:   testUserToken,
+		Projects:    projects,
+	}
+	spdy = newExecInitSPDY()
+	recorder = execInit(spdy, `{"kubeconfig": {"username": "test", "namespace": "project-001", "namespaces": ["extra", "project-002"], "discoverNamespaces": true}}`)
+	if assert.Equal(t, http.StatusOK, recorder.Code) && assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2) {
+		namespaces := contextNamespaces(spdy.InputBuffers[1])
+		assert.Len(t, namespaces, constants.MaxKubeConfigContexts+1, "Should cap requested and discovered namespaces together")
+		assert.Equal(t, "extra", namespaces["test-extra-context"], "Should keep requested namespaces")
+		assert.Equal(t, "project-100", namespaces["test-project-100-context"], "Should keep discovered namespaces in alphabetical order")
+		assert.NotContains(t, namespaces, "test-project-101-context")
+	}
+
+	spdy = newExecInitSPDY()
+	recorder = execInit(spdy, `{"kubeconfig": {"username": "test", "namespaces": ["Invalid_Namespace"]}}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeInvalidRequest), errResp.Code)
+		assert.Contains(t, errResp.Details, "invalid namespace 'Invalid_Namespace'")
+	}
+	assert.Empty(t, spdy.InputBuffers, "Should not exec into container if namespaces are invalid")
+}
+
+func TestExecInitCredentialInjectors(t *testing.T) {
+	router := newExecTestRouter(t)
+	injectors, err := injector.New([]string{config.CredentialInjectorKubeConfig, config.CredentialInjectorOc, config.CredentialInjectorRegistryAuth}, "registry.example.com:5000")
+	if !assert.NoError(t, err) {
+		return
+	}
+	router.CredentialInjectors = injectors
+	execInit := func(spdy *optest.FakeSPDYExecutorProvider) *httptest.ResponseRecorder {
+		return serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`)
+	}
+	responseOutputs := map[string]string{
+		injector.KubeConfigPathCommand:   "/home/user/.kube/config\n",
+		injector.ReadKubeConfigCommand:   "/home/user/.kube/config\n",
+		injector.ReadRegistryAuthCommand: "/run/user/1000/containers/auth.json\n" + `{"auths": {"quay.io": {"auth": "b3RoZXI="}}}`,
+		"echo $SHELL":                    "test_shellcommand\n",
+	}
+
+	spdy := &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{ResponseOutputs: responseOutputs}}
+	recorder := execInit(spdy)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	var response api.ExecInitResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response)) {
+		assert.Equal(t, []api.InjectedCredential{
+			{Injector: "kubeconfig", Path: "/home/user/.kube/config"},
+			{Injector: "oc", Path: "/home/user/.kube/config"},
+			{Injector: "registry-auth", Path: "/run/user/1000/containers/auth.json"},
+		}, response.Credentials, "Should report injected credentials in order")
+	}
+	if assert.Len(t, spdy.InputBuffers, 7) {
+		assert.Equal(t, injector.KubeConfigPathCommand, spdy.InputBuffers[0])
+		assert.Contains(t, spdy.InputBuffers[1], "current-context: test-context")
+		assert.Equal(t, injector.ReadKubeConfigCommand, spdy.InputBuffers[2])
+		assert.Contains(t, spdy.InputBuffers[3], "current-context: test-namespace/0-0-0-0:9999/test")
+		assert.Equal(t, injector.ReadRegistryAuthCommand, spdy.InputBuffers[4])
+		var auth map[string]map[string]map[string]string
+		if assert.NoError(t, json.Unmarshal([]byte(spdy.InputBuffers[5]), &auth)) {
+			assert.Equal(t, map[string]map[string]string{
+				"quay.io":                   {"auth": "b3RoZXI="},
+				"registry.example.com:5000": {"auth": base64.StdEncoding.EncodeToString([]byte("openshift:" + testUserToken))},
+			}, auth["auths"], "Should add registry credentials and preserve others")
+		}
+	}
+
+	spdy = &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{
+		ResponseOutputs: responseOutputs,
+		ErrInputs:       []string{"REGISTRY_AUTH_FILE"},
+	}}
+	recorder = execInit(spdy)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeCredentialInjectionFailed), errResp.Code)
+	}
+}
+
+func TestExecRefresh(t *testing.T) {
+	router := newExecTestRouter(t)
+	kubeconfig, err := util.CreateKubeConfigText(util.User{Token: "old-token"}, config.ClusterOverrides{}, "test-namespace", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	refresh := func(spdy *optest.FakeSPDYExecutorProvider) *httptest.ResponseRecorder {
+		return serveExecRequest(router, spdy, "/exec/refresh", `{"kubeconfig": {"username": "test"}}`)
+	}
+
+	spdy := &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{injector.ReadKubeConfigCommand: "/home/user/.kube/config\n" + kubeconfig},
+		},
+	}
+	recorder := refresh(spdy)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	assert.JSONEq(t, `{"pod": "test-terminal-pod", "container": "web-terminal-tooling", "credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`, recorder.Body.String())
+	if assert.Len(t, spdy.InputBuffers, 2, "Should read and write kubeconfig") {
+		updated := spdy.InputBuffers[1]
+		assert.Contains(t, updated, "token: "+testUserToken)
+		assert.NotContains(t, updated, "old-token")
+		assert.Contains(t, updated, "current-context: test-context", "Should not change context")
+		assert.Contains(t, updated, "namespace: test-namespace", "Should not change context")
+	}
+
+	spdy = &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{injector.ReadKubeConfigCommand: "/home/user/.kube/config\n"},
+		},
+	}
+	recorder = refresh(spdy)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeKubeconfigNotFound), errResp.Code)
+	}
+	assert.Len(t, spdy.InputBuffers, 1, "Should not write kubeconfig if it does not exist")
+}
+
+func TestExecRefreshCredentialInjectors(t *testing.T) {
+	router := newExecTestRouter(t)
+	kubeconfig, err := util.MergeOcKubeConfig("", util.User{Token: "old-token"}, config.ClusterOverrides{}, "test-namespace", "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	injectors, err := injector.New([]string{config.CredentialInjectorOc, config.CredentialInjectorRegistryAuth}, "registry.example.com:5000")
+	if !assert.NoError(t, err) {
+		return
+	}
+	tracker := injector.NewTracker()
+	router.CredentialInjectors = injectors
+	router.CredentialTracker = tracker
+	refresh := func(spdy *optest.FakeSPDYExecutorProvider) *httptest.ResponseRecorder {
+		return serveExecRequest(router, spdy, "/exec/refresh", `{"kubeconfig": {"username": "test"}}`)
+	}
+	responseOutputs := map[string]string{
+		injector.ReadKubeConfigCommand:   "/home/user/.kube/config\n" + kubeconfig,
+		injector.ReadRegistryAuthCommand: "/run/user/1000/containers/auth.json\n" + `{"auths": {"quay.io": {"auth": "b3RoZXI="}, "registry.example.com:5000": {"auth": "b3BlbnNoaWZ0Om9sZC10b2tlbg=="}}}`,
+	}
+	expectedCredentials := []api.InjectedCredential{
+		{Injector: "oc", Path: "/home/user/.kube/config"},
+		{Injector: "registry-auth", Path: "/run/user/1000/containers/auth.json"},
+	}
+
+	spdy := &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{ResponseOutputs: responseOutputs}}
+	recorder := refresh(spdy)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	var response api.ExecRefreshResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response)) {
+		assert.Equal(t, expectedCredentials, response.Credentials, "Should report refreshed credentials in order")
+	}
+	if assert.Len(t, spdy.InputBuffers, 4) {
+		assert.Equal(t, injector.ReadKubeConfigCommand, spdy.InputBuffers[0])
+		var updated util.KubeConfig
+		if assert.NoError(t, yaml.Unmarshal([]byte(spdy.InputBuffers[1]), &updated)) {
+			assert.Equal(t, []util.Users{{Name: "test/0-0-0-0:9999", User: util.User{Token: testUserToken}}}, updated.Users, "Should refresh user named as by oc login")
+			assert.Equal(t, "test-namespace/0-0-0-0:9999/test", updated.CurrentContext, "Should not change context")
+		}
+		assert.Equal(t, injector.ReadRegistryAuthCommand, spdy.InputBuffers[2])
+		var auth map[string]map[string]map[string]string
+		if assert.NoError(t, json.Unmarshal([]byte(spdy.InputBuffers[3]), &auth)) {
+			assert.Equal(t, map[string]map[string]string{
+				"quay.io":                   {"auth": "b3RoZXI="},
+				"registry.example.com:5000": {"auth": base64.StdEncoding.EncodeToString([]byte("openshift:" + testUserToken))},
+			}, auth["auths"], "Should replace registry credentials and preserve others")
+		}
+	}
+
+	spdy = &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{ResponseOutputs: responseOutputs}}
+	client, restconfig, err := optest.FakeClientProvider{}.NewClientWithToken(context.Background(), testUserToken)
+	if !assert.NoError(t, err) {
+		return
+	}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = spdy.NewFakeSPDYExecutor
+	removed, err := tracker.Remove(context.Background(), client, restconfig)
+	operations.NewSPDYExecutor = oldSPDYExecutor
+	if assert.NoError(t, err) {
+		assert.Equal(t, expectedCredentials, removed, "Should track refreshed credentials")
+	}
+
+	spdy = &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{
+		ResponseOutputs: responseOutputs,
+		ErrInputs:       []string{"REGISTRY_AUTH_FILE"},
+	}}
+	recorder = refresh(spdy)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeCredentialInjectionFailed), errResp.Code)
+	}
+}
+
+func TestExecLogout(t *testing.T) {
+	router := newExecTestRouter(t)
+	credentialStore, err := credentials.NewStore("127.0.0.1:4445")
+	if !assert.NoError(t, err) {
+		return
+	}
+	router.CredentialStore = credentialStore
+	router.CredentialTracker = injector.NewTracker()
+	router.UserCache = auth.NewUserCache(time.Minute, time.Minute, 10)
+
+	spdy := newExecInitSPDY()
+	recorder := serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test", "namespace": "test-namespace"}}`)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) || !assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2) {
+		return
+	}
+	kubeconfig := spdy.InputBuffers[1]
+
+	spdy = &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{injector.ReadKubeConfigCommand: "/home/user/.kube/config\n" + kubeconfig},
+			ErrInputs:       []string{"apiVersion: v1"},
+		},
+	}
+	recorder = serveExecRequest(router, spdy, "/exec/logout", "")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	var errResp api.ErrorResponse
+	if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+		assert.Equal(t, string(errors.CodeCredentialRemovalFailed), errResp.Code)
+		assert.Regexp(t, "^no credentials were removed: failed to remove kubeconfig credentials", errResp.Details)
+	}
+	_, err = router.CredentialStore.ExecCredential()
+	assert.NoError(t, err, "Should keep plugin token if credentials could not be removed")
+
+	spdy = &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{injector.ReadKubeConfigCommand: "/home/user/.kube/config\n" + kubeconfig},
+		},
+	}
+	recorder = serveExecRequest(router, spdy, "/exec/logout", "")
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	assert.JSONEq(t, `{"credentials": [{"injector": "kubeconfig", "path": "/home/user/.kube/config"}]}`, recorder.Body.String(), "Should retry credentials that could not be removed")
+	if assert.Len(t, spdy.InputBuffers, 2, "Should read and write kubeconfig") {
+		var removed util.KubeConfig
+		if assert.NoError(t, yaml.Unmarshal([]byte(spdy.InputBuffers[1]), &removed)) {
+			assert.Equal(t, []util.Users{{Name: "test"}}, removed.Users, "Should blank user credentials")
+			assert.Equal(t, "test-context", removed.CurrentContext, "Should not change context")
+		}
+	}
+	_, err = router.CredentialStore.ExecCredential()
+	assert.ErrorIs(t, err, credentials.ErrNoToken, "Should discard plugin token once credentials are removed")
+
+	spdy = &optest.FakeSPDYExecutorProvider{}
+	recorder = serveExecRequest(router, spdy, "/exec/logout", "")
+	if assert.Equal(t, http.StatusOK, recorder.Code) {
+		assert.JSONEq(t, `{"credentials": []}`, recorder.Body.String())
+	}
+	assert.Empty(t, spdy.InputBuffers, "Should not exec into container once credentials are removed")
+
+	lookups := 0
+	_, err = router.UserCache.Lookup(context.Background(), testUserToken, func(context.Context, string) (*auth.UserInfo, error) {
+		lookups++
+		return &auth.UserInfo{}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lookups, "Should remove token from user cache")
+}
+
+func TestCredentialPlugin(t *testing.T) {
+	router := newExecTestRouter(t)
+	store, err := credentials.NewStore("127.0.0.1:4445")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var key string
+	for _, envVar := range store.ExecConfig().Env {
+		if envVar.Name == "WEB_TERMINAL_CREDENTIAL_KEY" {
+			key = envVar.Value
+		}
+	}
+	router.CredentialStore = store
+	pluginHandler := router.CredentialPluginHandler()
+	getCredential := func(method, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/credential", nil)
+		if key != "" {
+			req.Header.Add("X-Credential-Key", key)
+		}
+		recorder := httptest.NewRecorder()
+		pluginHandler.ServeHTTP(recorder, req)
+		return recorder
+	}
+	assertErrorCode := func(recorder *httptest.ResponseRecorder, statusCode int, code errors.ErrorCode) {
+		assert.Equal(t, statusCode, recorder.Code)
+		var errResp api.ErrorResponse
+		if assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errResp)) {
+			assert.Equal(t, string(code), errResp.Code)
+		}
+	}
+
+	assertErrorCode(getCredential("GET", key), http.StatusServiceUnavailable, errors.CodeCredentialUnavailable)
+	assertErrorCode(getCredential("GET", ""), http.StatusUnauthorized, errors.CodeUnauthorized)
+	assertErrorCode(getCredential("GET", "invalid"), http.StatusUnauthorized, errors.CodeUnauthorized)
+	assertErrorCode(getCredential("POST", key), http.StatusMethodNotAllowed, errors.CodeMethodNotAllowed)
+
+	spdy := newExecInitSPDY()
+	recorder := serveExecRequest(router, spdy, "/exec/init", `{"kubeconfig": {"username": "test"}}`)
+	if !assert.Equal(t, http.StatusOK, recorder.Code) {
+		return
+	}
+	if assert.GreaterOrEqual(t, len(spdy.InputBuffers), 2, "Should write kubeconfig") {
+		written := spdy.InputBuffers[1]
+		assert.NotContains(t, written, testUserToken, "Should not write token to kubeconfig")
+		var kubeconfig util.KubeConfig
+		if assert.NoError(t, yaml.Unmarshal([]byte(written), &kubeconfig)) && assert.Len(t, kubeconfig.Users, 1) {
+			assert.Equal(t, store.ExecConfig(), kubeconfig.Users[0].User.Exec, "Should configure exec credential plugin")
+		}
+	}
+
+	recorder = getCredential("GET", key)
+	if assert.Equal(t, http.StatusOK, recorder.Code) {
+		assert.JSONEq(t, `{"apiVersion": "client.authentication.k8s.io/v1", "kind": "ExecCredential", "spec": {"interactive": false}, "status": {"token": "test-user-token"}}`, recorder.Body.String())
+	}
+
+	spdy.InputBuffers = nil
+	recorder = serveExecRequest(router, spdy, "/exec/refresh", `{"kubeconfig": {"username": "test"}}`)
+	if assert.Equal(t, http.StatusOK, recorder.Code) {
+		assert.JSONEq(t, `{"pod": "test-terminal-pod", "container": "web-terminal-tooling"}`, recorder.Body.String())
+	}
+	assert.Empty(t, spdy.InputBuffers, "Should not rewrite kubeconfig when using exec credential plugin")
+}
+
+func TestMetrics(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			UserToken: testUserToken,
+		},
+	}
+	handler := router.HTTPSHandler()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/activity/tick", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/sessions/test-id", nil))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `web_terminal_exec_http_requests_total{code="200",endpoint="/healthz",method="GET"}`)
+	assert.Contains(t, body, `web_terminal_exec_http_requests_total{code="401",endpoint="/activity/tick",method="POST"}`)
+	assert.Contains(t, body, `web_terminal_exec_http_request_duration_seconds_bucket{code="401",endpoint="/sessions/{id}",method="DELETE"`, "Should label requests by route rather than path")
+	assert.Regexp(t, `(?m)^web_terminal_exec_auth_failures_total [1-9]`, body)
+	assert.Contains(t, body, `web_terminal_exec_exec_init_failures_total{stage="pod_lookup"}`)
+	assert.Contains(t, body, `web_terminal_exec_workspace_stop_attempts_total{result="failure"}`)
+	assert.Contains(t, body, `web_terminal_exec_idle_timer_resets_total`)
+}
+
+func TestSessionRecording(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+
+	executor := optest.EchoSPDYExecutorProvider{}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = executor.NewEchoSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	clientProvider := optest.FakeClientProvider{UserToken: testUserToken}
+	router := Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, t.TempDir()),
+		ClientProvider:  clientProvider,
+	}
+	handler := router.HTTPSHandler()
+	getRecording := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/sessions/"+sessionID+"/recording", nil)
+		req.Header.Add("X-Access-Token", testUserToken)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	client, restconfig, err := clientProvider.NewClientWithToken(context.Background(), testUserToken)
+	assert.NoError(t, err)
+	termSession, err := router.SessionRegistry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, termSession.Info().Recorded)
+	termSession.Resize(remotecommand.TerminalSize{Width: 120, Height: 40})
+	assert.NoError(t, termSession.Input([]byte("ls -l\n")))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(getRecording(termSession.ID).Body.String(), "ls -l")
+	}, time.Second, 10*time.Millisecond, "Recording should be available while session is running")
+
+	termSession.Terminate()
+	<-termSession.Done()
+	recorder := getRecording(termSession.ID)
+	assert.Equal(t, http.StatusOK, recorder.Code, "Recording should be available after session ends")
+	assert.Equal(t, "application/x-asciicast", recorder.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"version":2`)
+		assert.Contains(t, lines[1], `"r","120x40"`)
+		assert.Contains(t, lines[2], `"o","ls -l\n"`)
+	}
+
+	assert.Equal(t, http.StatusNotFound, getRecording("0123456789abcdef0123456789abcdef").Code)
+	assert.Equal(t, http.StatusNotFound, getRecording("..%2F..%2Fetc%2Fpasswd").Code)
+
+	router.SessionRegistry = session.NewRegistry(testScrollbackBytes, "")
+	handler = router.HTTPSHandler()
+	assert.Equal(t, http.StatusNotFound, getRecording(termSession.ID).Code, "Recordings should not be served when recording is disabled")
+}
+
 func loadPodFromFile(t *testing.T, filepath string) []runtime.Object {
 	podbytes, err := os.ReadFile(path.Join("testdata", filepath))
 	if err != nil {
@@ -410,3 +1395,50 @@ func loadPodFromFile(t *testing.T, filepath string) []runtime.Object {
 
 	return []runtime.Object{pod}
 }
+
+// newExecTestRouter returns a Router for a workspace that runs the pod in testdata/pod.yaml, for tests of the exec
+// endpoints. Config and the environment are set up as for a pod in the cluster, and reset when the test completes.
+func newExecTestRouter(t *testing.T) *Router {
+	logrus.SetOutput(io.Discard)
+	setConfigForTest()
+	t.Cleanup(config.ResetConfigForTest)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "9999")
+
+	noOpActivityManager, err := activity.NewActivityManager(-1, -1, optest.NoOpClientProvider{})
+	assert.NoError(t, err)
+	return &Router{
+		ActivityManager: noOpActivityManager,
+		SessionRegistry: session.NewRegistry(testScrollbackBytes, ""),
+		ClientProvider: optest.FakeClientProvider{
+			InitialObjs: loadPodFromFile(t, "pod.yaml"),
+			UserToken:   testUserToken,
+		},
+	}
+}
+
+// newExecInitSPDY returns a fake executor that responds to the commands run by /exec/init with the default
+// credential injector.
+func newExecInitSPDY() *optest.FakeSPDYExecutorProvider {
+	return &optest.FakeSPDYExecutorProvider{
+		FakeSPDYExecutor: optest.FakeSPDYExecutor{
+			ResponseOutputs: map[string]string{
+				injector.KubeConfigPathCommand: "/home/user/.kube/config\n",
+				"echo $SHELL":                  "test_shellcommand\n",
+			},
+		},
+	}
+}
+
+// serveExecRequest sends a POST request with body and the test user's token to endpoint, using spdy for any exec
+// calls into the workspace pod.
+func serveExecRequest(router *Router, spdy *optest.FakeSPDYExecutorProvider, endpoint, body string) *httptest.ResponseRecorder {
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = spdy.NewFakeSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+	req := httptest.NewRequest("POST", endpoint, bytes.NewBuffer([]byte(body)))
+	req.Header.Add("X-Access-Token", testUserToken)
+	recorder := httptest.NewRecorder()
+	router.HTTPSHandler().ServeHTTP(recorder, req)
+	return recorder
+}
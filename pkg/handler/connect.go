@@ -0,0 +1,277 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/session"
+	"github.com/redhat-developer/web-terminal-exec/pkg/util"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		handleError(w, r, errors.NewHTTPError(status, errors.CodeInvalidRequest, reason.Error()))
+	},
+}
+
+func (s *Router) handleExecConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	log := logging.FromContext(r.Context())
+	var termSession *session.Session
+	isNewSession := false
+	if sessionID := r.URL.Query().Get("session"); sessionID != "" {
+		existingSession, ok := s.SessionRegistry.Get(sessionID)
+		if !ok {
+			handleError(w, r, errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeSessionNotFound, "session '%s' not found", sessionID))
+			return
+		}
+		// Sessions may be reattached only by the user that started them, as other users with access to the
+		// terminal could otherwise take over the shell
+		if existingSession.OwnerUID != requestUserUID(r) {
+			handleError(w, r, errors.NewHTTPErrorf(http.StatusForbidden, errors.CodeForbidden, "session '%s' was started by another user", sessionID))
+			return
+		}
+		termSession = existingSession
+	} else {
+		newSession, err := s.startSession(r)
+		if err != nil {
+			handleError(w, r, err)
+			return
+		}
+		termSession, isNewSession = newSession, true
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade writes an error response to the client on failure
+		log.Errorf("Failed to upgrade connection: %s", err)
+		if isNewSession {
+			termSession.Terminate()
+		}
+		return
+	}
+	defer conn.Close()
+	// Clear deadlines set by the HTTP server, as they would otherwise terminate long-lived sessions
+	if err := conn.UnderlyingConn().SetDeadline(time.Time{}); err != nil {
+		log.Errorf("Failed to reset connection deadline: %s", err)
+		if isNewSession {
+			termSession.Terminate()
+		}
+		return
+	}
+
+	terminal := newWebSocketTerminal(conn, log)
+	if err := terminal.writeJSON(api.TerminalMessage{Type: api.TerminalMessageSession, SessionID: termSession.ID}); err != nil {
+		log.Errorf("Failed to send session information to terminal: %s", err)
+		// The client never learned the session ID, so it cannot reattach to a new session
+		if isNewSession {
+			termSession.Terminate()
+		}
+		return
+	}
+	if err := termSession.Attach(terminal); err != nil {
+		log.Errorf("Failed to attach to session %s: %s", termSession.ID, err)
+		terminal.close(websocket.CloseGoingAway, "terminal session ended")
+		return
+	}
+	defer termSession.Detach(terminal)
+	log.Debugf("Attached to session %s in container %s", termSession.ID, termSession.ContainerName)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go terminal.readMessages(termSession)
+	go terminal.keepAlive(ctx)
+
+	select {
+	case <-termSession.Done():
+		terminal.close(websocket.CloseNormalClosure, "terminal session ended")
+	case <-terminal.detached:
+		terminal.close(websocket.CloseNormalClosure, "terminal attached from another connection")
+	case <-terminal.disconnected:
+		log.Debugf("Detached from session %s", termSession.ID)
+	}
+}
+
+// requestUserUID returns the UID of the user authenticated for r.
+func requestUserUID(r *http.Request) string {
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		return user.UID
+	}
+	return ""
+}
+
+// startSession starts a new terminal session running the default shell in the container specified
+// by the request.
+func (s *Router) startSession(r *http.Request) (*session.Session, error) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
+	token, err := auth.ExtractToken(r)
+	if err != nil {
+		return nil, errors.NewHTTPErrorf(http.StatusUnauthorized, errors.CodeUnauthorized, "failed to get token from request: %s", err)
+	}
+
+	userClient, userConfig, err := s.ClientProvider.NewClientWithToken(ctx, token)
+	if err != nil {
+		log.Errorf("Failed to create client: %s", err)
+		return nil, errors.NewHTTPError(http.StatusInternalServerError, errors.CodeClientCreationFailed, "Failed to create API client")
+	}
+
+	workspacePod, err := operations.GetCurrentWorkspacePod(ctx, userClient)
+	if err != nil {
+		log.Errorf("Failed to get current workspace pod: %s", err)
+		return nil, podLookupError(err)
+	}
+
+	params := &api.InitParams{ContainerName: r.URL.Query().Get("container")}
+	containerName, err := getContainerNameForExec(params, workspacePod)
+	if err != nil {
+		return nil, err
+	}
+
+	shell, err := util.DetectShell(ctx, userClient, userConfig, workspacePod.Name, containerName)
+	if err != nil {
+		return nil, errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeShellDetectionFailed, "Failed to detect shell in container '%s'", containerName).WithDetails(err.Error())
+	}
+
+	return s.SessionRegistry.Start(ctx, userClient, userConfig, workspacePod.Name, containerName, shell, requestUserUID(r))
+}
+
+// webSocketTerminal adapts a WebSocket connection to a terminal that can be attached to a session.
+type webSocketTerminal struct {
+	conn         *websocket.Conn
+	log          *logrus.Entry
+	writeMutex   sync.Mutex
+	detachOnce   sync.Once
+	detached     chan struct{}
+	disconnected chan struct{}
+}
+
+var _ session.Terminal = (*webSocketTerminal)(nil)
+
+func newWebSocketTerminal(conn *websocket.Conn, log *logrus.Entry) *webSocketTerminal {
+	return &webSocketTerminal{
+		conn:         conn,
+		log:          log,
+		detached:     make(chan struct{}),
+		disconnected: make(chan struct{}),
+	}
+}
+
+// readMessages reads messages from the client until the connection is closed, forwarding input and
+// resize messages to the session. Should be run in a goroutine.
+func (t *webSocketTerminal) readMessages(termSession *session.Session) {
+	defer close(t.disconnected)
+	t.conn.SetReadLimit(constants.MaxTerminalMessageBytes)
+	if err := t.conn.SetReadDeadline(time.Now().Add(constants.TerminalPongWait)); err != nil {
+		t.log.Errorf("Failed to set read deadline on terminal connection: %s", err)
+		return
+	}
+	t.conn.SetPongHandler(func(string) error {
+		return t.conn.SetReadDeadline(time.Now().Add(constants.TerminalPongWait))
+	})
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				t.log.Infof("Terminal connection closed unexpectedly: %s", err)
+			}
+			return
+		}
+		message := &api.TerminalMessage{}
+		if err := json.Unmarshal(data, message); err != nil {
+			t.log.Debugf("Ignoring malformed terminal message: %s", err)
+			continue
+		}
+		switch message.Type {
+		case api.TerminalMessageInput:
+			if err := termSession.Input([]byte(message.Data)); err != nil {
+				t.log.Debugf("Failed to write input to session %s: %s", termSession.ID, err)
+				return
+			}
+		case api.TerminalMessageResize:
+			termSession.Resize(remotecommand.TerminalSize{Width: message.Cols, Height: message.Rows})
+		default:
+			t.log.Debugf("Ignoring unknown terminal message type '%s'", message.Type)
+		}
+	}
+}
+
+// keepAlive periodically pings the client to keep idle connections open through proxies.
+func (t *webSocketTerminal) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(constants.TerminalPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(constants.TerminalWriteWait)); err != nil {
+				t.log.Debugf("Failed to ping terminal connection: %s", err)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *webSocketTerminal) Write(p []byte) (int, error) {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	if err := t.conn.SetWriteDeadline(time.Now().Add(constants.TerminalWriteWait)); err != nil {
+		return 0, err
+	}
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Detach is called by the session when another terminal attaches to it.
+func (t *webSocketTerminal) Detach() {
+	t.detachOnce.Do(func() {
+		close(t.detached)
+	})
+}
+
+func (t *webSocketTerminal) writeJSON(message interface{}) error {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	if err := t.conn.SetWriteDeadline(time.Now().Add(constants.TerminalWriteWait)); err != nil {
+		return err
+	}
+	return t.conn.WriteJSON(message)
+}
+
+func (t *webSocketTerminal) close(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	if err := t.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(constants.TerminalWriteWait)); err != nil {
+		t.log.Debugf("Failed to send close message to terminal connection: %s", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package handler
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/credentials"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+)
+
+// CredentialPluginHandler returns the handler for the credential plugin server, which serves the token in the
+// CredentialStore to the exec credential plugin in the kubeconfig. It is intended to be served over plain HTTP on
+// a loopback address, so that it is only reachable from within the workspace pod.
+func (s *Router) CredentialPluginHandler() http.Handler {
+	mux := http.NewServeMux()
+	requestIDMiddleware := requestIDMiddleware{}
+	loggingMiddleware := logRequestMiddleware{constants.CredentialPluginEndpoint}
+	handler := loggingMiddleware.addMiddleware(http.HandlerFunc(s.handleCredential))
+	mux.Handle(constants.CredentialPluginEndpoint, requestIDMiddleware.addMiddleware(handler))
+	mux.Handle("/", requestIDMiddleware.addMiddleware(http.HandlerFunc(handleNotFound)))
+	return mux
+}
+
+func (s *Router) handleCredential(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	if s.CredentialStore == nil {
+		handleNotFound(w, r)
+		return
+	}
+	if !s.CredentialStore.CheckKey(r.Header.Get(constants.CredentialKeyHeader)) {
+		handleError(w, r, errors.NewHTTPErrorf(http.StatusUnauthorized, errors.CodeUnauthorized, "%s header is missing or invalid", constants.CredentialKeyHeader))
+		return
+	}
+
+	log := logging.FromContext(r.Context())
+	credential, err := s.CredentialStore.ExecCredential()
+	if err != nil {
+		if stderrors.Is(err, credentials.ErrNoToken) || stderrors.Is(err, credentials.ErrTokenExpired) {
+			handleError(w, r, errors.NewHTTPErrorf(http.StatusServiceUnavailable, errors.CodeCredentialUnavailable, "%s; reopen the web terminal to provide a new token", err))
+			return
+		}
+		log.Errorf("Failed to get credential: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to get credential"))
+		return
+	}
+	responseJson, err := json.Marshal(credential)
+	if err != nil {
+		log.Errorf("Failed to marshal json response: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to marshal json response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(responseJson); err != nil {
+		log.Errorf("Failed to write response to %s request", constants.CredentialPluginEndpoint)
+	}
+}
@@ -10,13 +10,26 @@
 
 package handler
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
+)
 
 func (s *Router) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.Header().Add("Allow", http.MethodGet)
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		handleMethodNotAllowed(w, r, http.MethodGet)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+var metricsHandler = metrics.Handler()
+
+func (s *Router) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	metricsHandler.ServeHTTP(w, r)
+}
@@ -11,107 +11,247 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/api"
 	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
 	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/injector"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
 	"github.com/redhat-developer/web-terminal-exec/pkg/util"
-	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 )
 
-const createKubeConfigCommandFmt = `
-set -ex
-echo "test"
-if [ -z "$KUBECONFIG" ]; then
-	KUBECONFIG_DIR="$HOME/.kube"
-	KUBECONFIG_FILE="config"
-else
-	KUBECONFIG_DIR="$(dirname "$KUBECONFIG")"
-	KUBECONFIG_FILE="$(basename "$KUBECONFIG")"
-fi
-mkdir -p $KUBECONFIG_DIR
-cat <<EOF > "$KUBECONFIG_DIR/$KUBECONFIG_FILE"
-%s
-EOF
-`
-
 func (s *Router) handleExecInit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		w.Header().Add("Allow", http.MethodPost)
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		handleMethodNotAllowed(w, r, http.MethodPost)
 		return
 	}
 
+	log := logging.FromContext(r.Context())
 	params, err := readInitParams(w, r)
 	if err != nil {
-		handleError(w, err)
+		handleError(w, r, err)
 		return
 	}
-
-	userClient, userConfig, err := s.ClientProvider.NewClientWithToken(params.KubeConfigParams.BearerToken)
+	clusterOverrides, err := requestClusterOverrides(params)
 	if err != nil {
-		logrus.Errorf("Failed to create client: %s", err)
-		http.Error(w, "Failed to create API client", http.StatusInternalServerError)
+		handleError(w, r, err)
+		return
+	}
+	if err := validateNamespaces(params.Namespaces); err != nil {
+		handleError(w, r, errors.NewHTTPError(http.StatusBadRequest, errors.CodeInvalidRequest, "invalid namespaces in exec/init parameters").WithDetails(err.Error()))
 		return
 	}
 
-	workspacePod, err := operations.GetCurrentWorkspacePod(userClient)
+	ctx := r.Context()
+	spanCtx, span := tracing.StartSpan(ctx, "NewClientWithToken")
+	userClient, userConfig, err := s.ClientProvider.NewClientWithToken(spanCtx, params.KubeConfigParams.BearerToken)
+	tracing.EndSpan(span, err)
 	if err != nil {
-		logrus.Errorf("Failed to get current workspace pod: %s", err)
-		http.Error(w, "Failed to find workspace pod", http.StatusInternalServerError)
+		log.Errorf("Failed to create client: %s", err)
+		handleError(w, r, errors.NewHTTPError(http.StatusInternalServerError, errors.CodeClientCreationFailed, "Failed to create API client"))
 		return
 	}
-	logrus.Debugf("Found workspace pod %s", workspacePod.Name)
 
-	containerName, err := getContainerNameForExec(params, workspacePod)
+	spanCtx, span = tracing.StartSpan(ctx, "GetCurrentWorkspacePod")
+	workspacePod, err := operations.GetCurrentWorkspacePod(spanCtx, userClient)
+	tracing.EndSpan(span, err)
 	if err != nil {
-		handleError(w, err)
+		log.Errorf("Failed to get current workspace pod: %s", err)
+		metrics.ExecInitFailed(metrics.ExecInitStagePodLookup)
+		handleError(w, r, podLookupError(err))
 		return
 	}
-	logrus.Debugf("Found container name %s", containerName)
+	log.Debugf("Found workspace pod %s", workspacePod.Name)
 
-	kubeconfig, err := util.CreateKubeConfigText(params.BearerToken, params.Namespace, params.Username)
+	containerName, err := getContainerNameForExec(params, workspacePod)
 	if err != nil {
-		handleError(w, err)
+		metrics.ExecInitFailed(metrics.ExecInitStageContainerSelect)
+		handleError(w, r, err)
 		return
 	}
-	createKubeConfigCommand := fmt.Sprintf(createKubeConfigCommandFmt, kubeconfig)
-	if stdout, stderr, err := operations.ExecCommandInPod(userClient, userConfig, workspacePod.Name, containerName, createKubeConfigCommand); err != nil {
-		logrus.Errorf("Failed to create kubeconfig in container %s workspace pod %s: %s", containerName, workspacePod.Name, err)
-		logrus.Debugf("Command stdout: %s", stdout.String())
-		logrus.Debugf("Command stderr: %s", stderr.String())
-		http.Error(w, "Failed to create kubeconfig in pod", http.StatusInternalServerError)
-		return
+	log.Debugf("Found container name %s", containerName)
+
+	if params.DiscoverNamespaces {
+		spanCtx, span = tracing.StartSpan(ctx, "DiscoverNamespaces")
+		discovered, err := s.discoverNamespaces(spanCtx, userClient, params.BearerToken)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			// Contexts are still created for the namespaces in the request
+			log.Warnf("Failed to discover namespaces: %s", err)
+		} else {
+			log.Debugf("Discovered %d namespaces", len(discovered))
+			namespaces := mergeNamespaces(params.Namespace, params.Namespaces, discovered)
+			if len(namespaces) > constants.MaxKubeConfigContexts {
+				log.Warnf("Adding contexts for only %d of %d namespaces", constants.MaxKubeConfigContexts, len(namespaces))
+				namespaces = namespaces[:constants.MaxKubeConfigContexts]
+			}
+			params.Namespaces = namespaces
+		}
+	}
+
+	target := injector.Target{
+		Client:        userClient,
+		Config:        userConfig,
+		PodName:       workspacePod.Name,
+		ContainerName: containerName,
+	}
+	injectRequest := injector.Request{
+		Params:      params,
+		Credentials: s.kubeConfigCredentials(params),
+		Cluster:     clusterOverrides,
+	}
+	var injected []api.InjectedCredential
+	for _, inj := range s.credentialInjectors() {
+		spanCtx, span = tracing.StartSpan(ctx, injectorSpanName("Inject", inj.Name()))
+		path, err := inj.Inject(spanCtx, target, injectRequest)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			metrics.ExecInitFailed(injectStage(inj))
+			handleError(w, r, err)
+			return
+		}
+		log.Debugf("Injected %s credentials into %s in container %s", inj.Name(), path, containerName)
+		injected = append(injected, api.InjectedCredential{Injector: inj.Name(), Path: path})
+		if s.CredentialTracker != nil {
+			s.CredentialTracker.Track(inj, target, path, injectRequest)
+		}
+	}
+	if s.CredentialStore != nil {
+		s.CredentialStore.SetToken(params.BearerToken)
 	}
-	logrus.Debugf("Created kubeconfig in container %s", containerName)
 
-	shell, err := util.DetectShell(userClient, userConfig, workspacePod.Name, containerName)
+	spanCtx, span = tracing.StartSpan(ctx, "DetectShell")
+	shell, err := util.DetectShell(spanCtx, userClient, userConfig, workspacePod.Name, containerName)
+	tracing.EndSpan(span, err)
 	if err != nil {
-		handleError(w, err)
+		metrics.ExecInitFailed(metrics.ExecInitStageShellDetect)
+		handleError(w, r, errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeShellDetectionFailed, "Failed to detect shell in container '%s'", containerName).WithDetails(err.Error()))
+		return
 	}
-	logrus.Debugf("Detected shell %s in container %s", shell, containerName)
+	log.Debugf("Detected shell %s in container %s", shell, containerName)
 
 	response := api.ExecInitResponse{
 		PodName:       workspacePod.Name,
 		ContainerName: containerName,
 		Cmd:           []string{shell},
+		TokenExpiry:   auth.TokenExpiry(params.BearerToken),
+		Credentials:   injected,
 	}
 	responseJson, err := json.Marshal(response)
 	if err != nil {
-		logrus.Errorf("Failed to marshal json response: %s", err)
-		http.Error(w, "Failed to marshal json response", http.StatusInternalServerError)
+		log.Errorf("Failed to marshal json response: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to marshal json response"))
 		return
 	}
 	if _, err := w.Write(responseJson); err != nil {
-		logrus.Errorf("Failed to write response to /exec/init request")
+		log.Errorf("Failed to write response to /exec/init request")
+	}
+}
+
+// kubeConfigCredentials returns the credentials of the user in the kubeconfig: an exec credential plugin that reads
+// the user's token from the CredentialStore if there is one, or the token itself otherwise.
+func (s *Router) kubeConfigCredentials(params *api.InitParams) util.User {
+	if s.CredentialStore != nil {
+		return util.User{Exec: s.CredentialStore.ExecConfig()}
 	}
+	return util.User{Token: params.BearerToken}
+}
+
+// credentialInjectors returns the injectors that write the user's credentials into the container.
+func (s *Router) credentialInjectors() []injector.Injector {
+	if s.CredentialInjectors == nil {
+		return injector.Default()
+	}
+	return s.CredentialInjectors
+}
+
+// requestClusterOverrides returns the cluster options in params, merged with those configured for the server.
+func requestClusterOverrides(params *api.InitParams) (config.ClusterOverrides, error) {
+	clusterOverrides := config.ClusterOverrides{
+		Server:                   params.Server,
+		CertificateAuthorityData: params.CertificateAuthorityData,
+		TLSServerName:            params.TLSServerName,
+		ProxyURL:                 params.ProxyURL,
+	}
+	if err := clusterOverrides.Validate(); err != nil {
+		return config.ClusterOverrides{}, errors.NewHTTPError(http.StatusBadRequest, errors.CodeInvalidRequest, "invalid cluster options in request parameters").WithDetails(err.Error())
+	}
+	// Options in the request take precedence over those configured for the server
+	return config.KubeConfigCluster.Override(clusterOverrides), nil
+}
+
+// injectorSpanName returns the name of the tracing span for the operation of the injector named name, e.g.
+// InjectRegistryAuth for operation Inject and registry-auth.
+func injectorSpanName(operation, name string) string {
+	spanName := operation
+	for _, word := range strings.Split(name, "-") {
+		if word != "" {
+			spanName += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return spanName
+}
+
+// injectStage returns the stage of /exec/init recorded in metrics if inj fails.
+func injectStage(inj injector.Injector) string {
+	switch inj.Name() {
+	case config.CredentialInjectorKubeConfig, config.CredentialInjectorOc:
+		return metrics.ExecInitStageKubeconfigWrite
+	default:
+		return metrics.ExecInitStageCredentialInject
+	}
+}
+
+// discoverNamespaces returns the namespaces that the user owning token can access.
+func (s *Router) discoverNamespaces(ctx context.Context, client kubernetes.Interface, token string) ([]string, error) {
+	projectClient, _, err := s.ClientProvider.NewOpenShiftUserClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return operations.ListUserNamespaces(ctx, projectClient, client)
+}
+
+// mergeNamespaces returns the requested namespaces followed by the discovered ones, without duplicates or the
+// namespace of the current context, for which a context is always created.
+func mergeNamespaces(namespace string, requested, discovered []string) []string {
+	var merged []string
+	seen := map[string]bool{namespace: true}
+	for _, name := range append(append([]string{}, requested...), discovered...) {
+		if !seen[name] {
+			seen[name] = true
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// validateNamespaces checks that the namespaces for which contexts are requested are valid namespace names, and
+// that there are not too many of them.
+func validateNamespaces(namespaces []string) error {
+	if len(namespaces) > constants.MaxKubeConfigContexts {
+		return fmt.Errorf("at most %d namespaces may be specified", constants.MaxKubeConfigContexts)
+	}
+	for _, namespace := range namespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace '%s': %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+	return nil
 }
 
 func getContainerNameForExec(params *api.InitParams, pod *corev1.Pod) (string, error) {
@@ -121,7 +261,7 @@ func getContainerNameForExec(params *api.InitParams, pod *corev1.Pod) (string, e
 				return container.Name, nil
 			}
 		}
-		return "", errors.NewHTTPErrorf(http.StatusBadRequest, "container '%s' not found in pod '%s'", params.ContainerName, pod.Name)
+		return "", errors.NewHTTPErrorf(http.StatusBadRequest, errors.CodeContainerNotFound, "container '%s' not found in pod '%s'", params.ContainerName, pod.Name)
 	}
 
 	// Attempt to find a container:
@@ -131,7 +271,7 @@ func getContainerNameForExec(params *api.InitParams, pod *corev1.Pod) (string, e
 	filteredContainers := filterContainerList(pod.Spec.Containers)
 	switch len(filteredContainers) {
 	case 0:
-		return "", errors.NewHTTPErrorf(http.StatusBadRequest, "no suitable container found in pod '%s'", pod.Name)
+		return "", errors.NewHTTPErrorf(http.StatusBadRequest, errors.CodeNoSuitableContainer, "no suitable container found in pod '%s'", pod.Name)
 	case 1:
 		return filteredContainers[0].Name, nil
 	default:
@@ -144,24 +284,36 @@ func getContainerNameForExec(params *api.InitParams, pod *corev1.Pod) (string, e
 	}
 }
 
+// podLookupError converts an error returned by operations.GetCurrentWorkspacePod into an HTTP error.
+func podLookupError(err error) *errors.HTTPError {
+	switch {
+	case stderrors.Is(err, operations.ErrWorkspacePodNotFound):
+		return errors.NewHTTPError(http.StatusInternalServerError, errors.CodePodNotFound, "Workspace pod not found").WithDetails(err.Error())
+	case stderrors.Is(err, operations.ErrWorkspacePodNotRunning):
+		return errors.NewHTTPError(http.StatusInternalServerError, errors.CodePodNotRunning, "Workspace pod is not running").WithDetails(err.Error())
+	default:
+		return errors.NewHTTPError(http.StatusInternalServerError, errors.CodePodLookupFailed, "Failed to find workspace pod").WithDetails(err.Error())
+	}
+}
+
 func readInitParams(w http.ResponseWriter, r *http.Request) (*api.InitParams, error) {
 	params := &api.InitParams{}
 	r.Body = http.MaxBytesReader(w, r.Body, constants.MaxBodyBytes)
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
 		if err.Error() == "http: request body too large" {
-			return nil, errors.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body too large")
+			return nil, errors.NewHTTPError(http.StatusRequestEntityTooLarge, errors.CodeRequestTooLarge, "Request body too large")
 		}
 		return nil, errors.NewInternalErrorf("failed to read exec/init parameters from request: %s", err)
 	}
 	if len(reqBody) > 0 {
 		if err := json.Unmarshal(reqBody, params); err != nil {
-			return nil, errors.NewInternalErrorf("failed to unmarshal exec/init parameters from request: %s", err)
+			return nil, errors.NewHTTPError(http.StatusBadRequest, errors.CodeInvalidRequest, "failed to unmarshal exec/init parameters from request").WithDetails(err.Error())
 		}
 	}
 	token, err := auth.ExtractToken(r)
 	if err != nil {
-		return nil, errors.NewHTTPErrorf(http.StatusUnauthorized, "failed to get token from request: %s", err)
+		return nil, errors.NewHTTPErrorf(http.StatusUnauthorized, errors.CodeUnauthorized, "failed to get token from request: %s", err)
 	}
 	params.BearerToken = token
 	// Set defaults
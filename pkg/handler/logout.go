@@ -0,0 +1,94 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
+)
+
+// handleExecLogout removes the user's credentials from the containers into which /exec/init injected them,
+// discards the token served to the exec credential plugin, and removes the token from the UserCache. The token is
+// only discarded once all injected credentials are removed, so that a failed logout leaves the user logged in and
+// can be retried.
+func (s *Router) handleExecLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+
+	log := logging.FromContext(r.Context())
+	token, err := auth.ExtractToken(r)
+	if err != nil {
+		handleError(w, r, errors.NewHTTPErrorf(http.StatusUnauthorized, errors.CodeUnauthorized, "failed to get token from request: %s", err))
+		return
+	}
+
+	response := api.ExecLogoutResponse{Credentials: []api.InjectedCredential{}}
+	if s.CredentialTracker != nil {
+		ctx := r.Context()
+		spanCtx, span := tracing.StartSpan(ctx, "NewClientWithToken")
+		userClient, userConfig, err := s.ClientProvider.NewClientWithToken(spanCtx, token)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			log.Errorf("Failed to create client: %s", err)
+			handleError(w, r, errors.NewHTTPError(http.StatusInternalServerError, errors.CodeClientCreationFailed, "Failed to create API client"))
+			return
+		}
+
+		spanCtx, span = tracing.StartSpan(ctx, "RemoveCredentials")
+		removed, err := s.CredentialTracker.Remove(spanCtx, userClient, userConfig)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			log.Errorf("Failed to remove credentials: %s", err)
+			handleError(w, r, errors.NewHTTPError(http.StatusInternalServerError, errors.CodeCredentialRemovalFailed, "Failed to remove credentials from workspace containers").WithDetails(removalFailureDetails(removed, err)))
+			return
+		}
+		response.Credentials = append(response.Credentials, removed...)
+	}
+	if s.CredentialStore != nil {
+		s.CredentialStore.ClearToken()
+	}
+	// Authenticate the next request with this token against the API server rather than from the cache
+	s.UserCache.Invalidate(token)
+	log.Debugf("Removed %d injected credentials", len(response.Credentials))
+
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Failed to marshal json response: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to marshal json response"))
+		return
+	}
+	if _, err := w.Write(responseJson); err != nil {
+		log.Errorf("Failed to write response to /exec/logout request")
+	}
+}
+
+// removalFailureDetails describes a failure to remove injected credentials, listing the credentials that were
+// removed before the failure. Credentials that are not listed remain in place.
+func removalFailureDetails(removed []api.InjectedCredential, err error) string {
+	if len(removed) == 0 {
+		return fmt.Sprintf("no credentials were removed: %s", err)
+	}
+	var paths []string
+	for _, credential := range removed {
+		paths = append(paths, fmt.Sprintf("%s (%s)", credential.Path, credential.Injector))
+	}
+	return fmt.Sprintf("only removed %s: %s", strings.Join(paths, ", "), err)
+}
@@ -11,18 +11,44 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
-	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/metrics"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type middleware interface {
 	addMiddleware(http.Handler) http.Handler
 }
 
+// requestIDMiddleware assigns an ID to each request, or uses the ID provided in the X-Request-Id header
+// if it is valid. The ID is returned in the response headers and included in logs for the request.
+type requestIDMiddleware struct{}
+
+func (m *requestIDMiddleware) addMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(constants.RequestIDHeader)
+		if !logging.IsValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set(constants.RequestIDHeader, requestID)
+		handler.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
 type logRequestMiddleware struct {
 	path string
 }
@@ -30,26 +56,120 @@ type logRequestMiddleware struct {
 func (m *logRequestMiddleware) addMiddleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
-		handler.ServeHTTP(w, r)
+		statusWriter := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(statusWriter, r)
 		duration := time.Since(startTime)
-		logrus.WithFields(logrus.Fields{
+		metrics.ObserveRequest(m.path, r.Method, statusWriter.status, duration)
+		logging.FromContext(r.Context()).WithFields(logrus.Fields{
 			"endpoint": m.path,
 			"duration": duration.String(),
 			"method":   r.Method,
+			"status":   statusWriter.status,
 		}).Info()
 	})
 }
 
+// requestTimeoutMiddleware cancels requests that do not complete within timeout, and extends the server's write
+// deadline for them, so that the response to requests that take longer than constants.ServerWriteTimeout, but
+// less than timeout, is not dropped.
+type requestTimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+func (m *requestTimeoutMiddleware) addMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Leave time to write the response once the request's context is cancelled
+		deadline := time.Now().Add(m.timeout + constants.ServerWriteTimeout)
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			logging.FromContext(r.Context()).Debugf("Failed to extend write deadline: %s", err)
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), m.timeout)
+		defer cancel()
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type traceRequestMiddleware struct {
+	path string
+}
+
+func (m *traceRequestMiddleware) addMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.StartSpan(ctx, fmt.Sprintf("%s %s", r.Method, m.path),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPMethod(r.Method), semconv.HTTPRoute(m.path)))
+		defer span.End()
+		statusWriter := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(statusWriter, r.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPStatusCode(statusWriter.status))
+		if statusWriter.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusWriter.status))
+		}
+	})
+}
+
+// statusResponseWriter records the status code written to the wrapped ResponseWriter. It supports
+// hijacking the connection so that it can be used with WebSocket endpoints.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking connections")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && !w.wroteHeader {
+		// The protocol upgrade response is written directly to the hijacked connection
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 type authMiddleware struct {
-	clientProvider operations.ClientProvider
+	authenticator auth.Authenticator
+	authorizer    auth.Authorizer
+	cache         *auth.UserCache
+	// requiredAccess is the minimum access level required to use the endpoint
+	requiredAccess auth.AccessLevel
 }
 
 func (m *authMiddleware) addMiddleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if err := auth.Authenticate(r, m.clientProvider); err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+		_, span := tracing.StartSpan(r.Context(), "Authenticate")
+		user, access, err := auth.Authenticate(r, m.authenticator, m.authorizer, m.cache)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			metrics.AuthFailed()
+			handleError(w, r, errors.NewHTTPError(http.StatusUnauthorized, errors.CodeUnauthorized, err.Error()))
+			return
+		}
+		if access < m.requiredAccess {
+			handleError(w, r, errors.NewHTTPErrorf(http.StatusForbidden, errors.CodeForbidden, "%s access is required to use this endpoint", m.requiredAccess))
 			return
 		}
-		handler.ServeHTTP(w, r)
+		handler.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
 	})
 }
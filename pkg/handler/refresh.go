@@ -0,0 +1,125 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/auth"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/injector"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/tracing"
+)
+
+// handleExecRefresh replaces the token in the credentials written by /exec/init with the token of the current
+// request, using the same injectors, without changing the kubeconfig's clusters or contexts. If the kubeconfig uses
+// the exec credential plugin, the token in the CredentialStore is replaced instead of the one in the kubeconfig.
+func (s *Router) handleExecRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		handleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+
+	log := logging.FromContext(r.Context())
+	params, err := readInitParams(w, r)
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	clusterOverrides, err := requestClusterOverrides(params)
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	spanCtx, span := tracing.StartSpan(ctx, "NewClientWithToken")
+	userClient, userConfig, err := s.ClientProvider.NewClientWithToken(spanCtx, params.BearerToken)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to create client: %s", err)
+		handleError(w, r, errors.NewHTTPError(http.StatusInternalServerError, errors.CodeClientCreationFailed, "Failed to create API client"))
+		return
+	}
+
+	spanCtx, span = tracing.StartSpan(ctx, "GetCurrentWorkspacePod")
+	workspacePod, err := operations.GetCurrentWorkspacePod(spanCtx, userClient)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to get current workspace pod: %s", err)
+		handleError(w, r, podLookupError(err))
+		return
+	}
+
+	containerName, err := getContainerNameForExec(params, workspacePod)
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	if s.CredentialTracker != nil {
+		s.CredentialTracker.SetToken(params.BearerToken)
+	}
+	if s.CredentialStore != nil {
+		s.CredentialStore.SetToken(params.BearerToken)
+		log.Debugf("Refreshed credentials for exec credential plugin")
+	}
+
+	target := injector.Target{
+		Client:        userClient,
+		Config:        userConfig,
+		PodName:       workspacePod.Name,
+		ContainerName: containerName,
+	}
+	refreshRequest := injector.Request{
+		Params:      params,
+		Credentials: s.kubeConfigCredentials(params),
+		Cluster:     clusterOverrides,
+	}
+	var refreshed []api.InjectedCredential
+	for _, inj := range s.credentialInjectors() {
+		spanCtx, span = tracing.StartSpan(ctx, injectorSpanName("Refresh", inj.Name()))
+		path, err := inj.Refresh(spanCtx, target, refreshRequest)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			handleError(w, r, err)
+			return
+		}
+		if path == "" {
+			continue
+		}
+		log.Debugf("Refreshed %s credentials in %s in container %s", inj.Name(), path, containerName)
+		refreshed = append(refreshed, api.InjectedCredential{Injector: inj.Name(), Path: path})
+		if s.CredentialTracker != nil {
+			s.CredentialTracker.Track(inj, target, path, refreshRequest)
+		}
+	}
+
+	response := api.ExecRefreshResponse{
+		PodName:       workspacePod.Name,
+		ContainerName: containerName,
+		TokenExpiry:   auth.TokenExpiry(params.BearerToken),
+		Credentials:   refreshed,
+	}
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Failed to marshal json response: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to marshal json response"))
+		return
+	}
+	if _, err := w.Write(responseJson); err != nil {
+		log.Errorf("Failed to write response to /exec/refresh request")
+	}
+}
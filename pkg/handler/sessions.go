@@ -0,0 +1,91 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+)
+
+func (s *Router) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	sessions := []api.SessionInfo{}
+	for _, session := range s.SessionRegistry.List() {
+		sessions = append(sessions, session.Info())
+	}
+	responseJson, err := json.Marshal(sessions)
+	if err != nil {
+		logging.FromContext(r.Context()).Errorf("Failed to marshal json response: %s", err)
+		handleError(w, r, errors.NewInternalError("Failed to marshal json response"))
+		return
+	}
+	if _, err := w.Write(responseJson); err != nil {
+		logging.FromContext(r.Context()).Errorf("Failed to write response to /sessions request")
+	}
+}
+
+func (s *Router) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		handleMethodNotAllowed(w, r, http.MethodDelete)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if !s.SessionRegistry.Terminate(sessionID) {
+		handleError(w, r, errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeSessionNotFound, "session '%s' not found", sessionID))
+		return
+	}
+	logging.FromContext(r.Context()).Infof("Terminated session %s", sessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Router) handleSessionRecording(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		handleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	recordingPath, err := s.SessionRegistry.RecordingPath(sessionID)
+	if err != nil {
+		handleError(w, r, errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeRecordingNotFound, "recording for session '%s' not found", sessionID).WithDetails(err.Error()))
+		return
+	}
+	recording, err := os.Open(recordingPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			handleError(w, r, errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeRecordingNotFound, "recording for session '%s' not found", sessionID))
+		} else {
+			handleError(w, r, fmt.Errorf("failed to open recording for session '%s': %s", sessionID, err))
+		}
+		return
+	}
+	defer recording.Close()
+	info, err := recording.Stat()
+	if err != nil {
+		handleError(w, r, fmt.Errorf("failed to read recording for session '%s': %s", sessionID, err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(recordingPath)))
+	http.ServeContent(w, r, filepath.Base(recordingPath), info.ModTime(), recording)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package injector writes files containing the user's credentials into a container of the workspace pod, so that
+// tools in the container can access the cluster as the user.
+package injector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/util"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Injector writes a file containing the user's credentials into a container.
+type Injector interface {
+	// Name is the name of the injector in config.CredentialInjectors and in the /exec/init response
+	Name() string
+	// Inject writes the credentials in request into the container in target, and returns the path of the file
+	// it wrote. Errors are returned as *errors.HTTPError.
+	Inject(ctx context.Context, target Target, request Request) (string, error)
+	// Refresh replaces the token in the credentials written by Inject with the token in request, preserving the
+	// rest of the file, and returns the path of the file it wrote. It returns an empty path if the credentials
+	// written by Inject do not contain the token. Errors are returned as *errors.HTTPError.
+	Refresh(ctx context.Context, target Target, request Request) (string, error)
+	// Remove blanks the credentials written by Inject for request in the container in target, preserving the
+	// rest of the file. It does nothing if the file does not exist.
+	Remove(ctx context.Context, target Target, request Request) error
+}
+
+// Target is the container into which credentials are injected.
+type Target struct {
+	Client        kubernetes.Interface
+	Config        *rest.Config
+	PodName       string
+	ContainerName string
+}
+
+// Request holds the credentials to inject and the options of the /exec/init request that they are injected for.
+type Request struct {
+	Params *api.InitParams
+	// Credentials are the credentials of the user in kubeconfigs, which may be an exec credential plugin rather
+	// than the user's token
+	Credentials util.User
+	// Cluster overrides the cluster in kubeconfigs
+	Cluster config.ClusterOverrides
+}
+
+// New returns the injectors with the given names, in order. registryHost is the registry for which the
+// registry-auth injector writes credentials.
+func New(names []string, registryHost string) ([]Injector, error) {
+	var injectors []Injector
+	for _, name := range names {
+		switch name {
+		case config.CredentialInjectorKubeConfig:
+			injectors = append(injectors, kubeConfigInjector{})
+		case config.CredentialInjectorOc:
+			injectors = append(injectors, ocInjector{})
+		case config.CredentialInjectorRegistryAuth:
+			injectors = append(injectors, registryAuthInjector{host: registryHost})
+		default:
+			return nil, fmt.Errorf("unknown credential injector '%s'", name)
+		}
+	}
+	return injectors, nil
+}
+
+// Default returns the injectors used if none are configured, which only write a kubeconfig.
+func Default() []Injector {
+	return []Injector{kubeConfigInjector{}}
+}
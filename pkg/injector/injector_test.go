@@ -0,0 +1,34 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	injectors, err := New([]string{"registry-auth", "kubeconfig", "oc"}, "registry.example.com")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Injector{registryAuthInjector{host: "registry.example.com"}, kubeConfigInjector{}, ocInjector{}}, injectors)
+		var names []string
+		for _, injector := range injectors {
+			names = append(names, injector.Name())
+		}
+		assert.Equal(t, []string{"registry-auth", "kubeconfig", "oc"}, names, "Should name injectors as in configuration")
+	}
+
+	_, err = New([]string{"kubeconfig", "docker"}, "")
+	assert.EqualError(t, err, "unknown credential injector 'docker'")
+
+	assert.Equal(t, []Injector{kubeConfigInjector{}}, Default())
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/util"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// kubeConfigPathScript sets KUBECONFIG_DIR and KUBECONFIG_FILE to the location of the kubeconfig in the container
+const kubeConfigPathScript = `
+if [ -z "$KUBECONFIG" ]; then
+	KUBECONFIG_DIR="$HOME/.kube"
+	KUBECONFIG_FILE="config"
+else
+	KUBECONFIG_DIR="$(dirname "$KUBECONFIG")"
+	KUBECONFIG_FILE="$(basename "$KUBECONFIG")"
+fi
+`
+
+// KubeConfigPathCommand prints the path of the kubeconfig in the container
+const KubeConfigPathCommand = kubeConfigPathScript + `echo "$KUBECONFIG_DIR/$KUBECONFIG_FILE"
+`
+
+// ReadKubeConfigCommand prints the path of the kubeconfig in the container on the first line, followed by its
+// contents if it exists
+const ReadKubeConfigCommand = kubeConfigPathScript + `echo "$KUBECONFIG_DIR/$KUBECONFIG_FILE"
+if [ -f "$KUBECONFIG_DIR/$KUBECONFIG_FILE" ]; then
+	cat "$KUBECONFIG_DIR/$KUBECONFIG_FILE"
+fi
+`
+
+// kubeConfigInjector writes a kubeconfig for the user, replacing the existing kubeconfig unless the request asks
+// for the generated entries to be merged into it.
+type kubeConfigInjector struct{}
+
+func (kubeConfigInjector) Name() string {
+	return config.CredentialInjectorKubeConfig
+}
+
+func (kubeConfigInjector) Inject(ctx context.Context, target Target, request Request) (string, error) {
+	if request.Params.Merge {
+		return mergeKubeConfig(ctx, target, func(existing string) (string, error) {
+			return util.MergeKubeConfig(existing, request.Credentials, request.Cluster, request.Params.Namespace, request.Params.Username, request.Params.Namespaces...)
+		})
+	}
+	return createKubeConfig(ctx, target, request)
+}
+
+func (kubeConfigInjector) Refresh(ctx context.Context, target Target, request Request) (string, error) {
+	return refreshKubeConfig(ctx, target, request, func(existing string) (string, error) {
+		return util.UpdateKubeConfigToken(existing, request.Params.Username, request.Credentials.Token)
+	})
+}
+
+func (kubeConfigInjector) Remove(ctx context.Context, target Target, request Request) error {
+	return removeKubeConfigCredentials(ctx, target, func(existing string) (string, error) {
+		return util.RemoveKubeConfigCredentials(existing, request.Params.Username)
+	})
+}
+
+// ocInjector merges a cluster, user and contexts for the user into the kubeconfig, named as `oc login` names them.
+type ocInjector struct{}
+
+func (ocInjector) Name() string {
+	return config.CredentialInjectorOc
+}
+
+func (ocInjector) Inject(ctx context.Context, target Target, request Request) (string, error) {
+	return mergeKubeConfig(ctx, target, func(existing string) (string, error) {
+		return util.MergeOcKubeConfig(existing, request.Credentials, request.Cluster, request.Params.Namespace, request.Params.Username, request.Params.Namespaces...)
+	})
+}
+
+func (ocInjector) Refresh(ctx context.Context, target Target, request Request) (string, error) {
+	return refreshKubeConfig(ctx, target, request, func(existing string) (string, error) {
+		return util.UpdateOcKubeConfigToken(existing, request.Cluster, request.Params.Username, request.Credentials.Token)
+	})
+}
+
+func (ocInjector) Remove(ctx context.Context, target Target, request Request) error {
+	return removeKubeConfigCredentials(ctx, target, func(existing string) (string, error) {
+		return util.RemoveOcKubeConfigCredentials(existing, request.Cluster, request.Params.Username)
+	})
+}
+
+// createKubeConfig writes a new kubeconfig for the user to the container, replacing any existing kubeconfig.
+func createKubeConfig(ctx context.Context, target Target, request Request) (string, error) {
+	log := logging.FromContext(ctx)
+	kubeconfig, err := util.CreateKubeConfigText(request.Credentials, request.Cluster, request.Params.Namespace, request.Params.Username, request.Params.Namespaces...)
+	if err != nil {
+		return "", errors.NewHTTPError(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to create kubeconfig").WithDetails(err.Error())
+	}
+	path, err := getKubeConfigPath(ctx, target.Client, target.Config, target.PodName, target.ContainerName)
+	if err == nil {
+		err = operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, []byte(kubeconfig))
+	}
+	if err != nil {
+		log.Errorf("Failed to create kubeconfig in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to create kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	return path, nil
+}
+
+// mergeKubeConfig replaces the kubeconfig in the container with the result of merge applied to its contents,
+// which are empty if it does not exist.
+func mergeKubeConfig(ctx context.Context, target Target, merge func(existing string) (string, error)) (string, error) {
+	log := logging.FromContext(ctx)
+	path, existing, err := ReadKubeConfig(ctx, target.Client, target.Config, target.PodName, target.ContainerName)
+	if err != nil {
+		log.Errorf("Failed to read kubeconfig in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to read kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	kubeconfig, err := merge(existing)
+	if err != nil {
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to merge kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	if err := operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, []byte(kubeconfig)); err != nil {
+		log.Errorf("Failed to write kubeconfig in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to write kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	return path, nil
+}
+
+// refreshKubeConfig replaces the kubeconfig in the container with the result of update applied to its contents.
+// Nothing is written if the kubeconfig uses an exec credential plugin rather than the user's token, and an error
+// with code CodeKubeconfigNotFound is returned if the kubeconfig does not exist.
+func refreshKubeConfig(ctx context.Context, target Target, request Request, update func(existing string) (string, error)) (string, error) {
+	if request.Credentials.Token == "" {
+		return "", nil
+	}
+	log := logging.FromContext(ctx)
+	path, existing, err := ReadKubeConfig(ctx, target.Client, target.Config, target.PodName, target.ContainerName)
+	if err != nil {
+		log.Errorf("Failed to read kubeconfig in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to read kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	if strings.TrimSpace(existing) == "" {
+		return "", errors.NewHTTPErrorf(http.StatusNotFound, errors.CodeKubeconfigNotFound, "kubeconfig not found in container '%s'; it is created by %s", target.ContainerName, constants.ExecInitEndpoint)
+	}
+	kubeconfig, err := update(existing)
+	if err != nil {
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to update kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	if err := operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, []byte(kubeconfig)); err != nil {
+		log.Errorf("Failed to update kubeconfig in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeKubeconfigWriteFailed, "Failed to update kubeconfig in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	return path, nil
+}
+
+// removeKubeConfigCredentials replaces the kubeconfig in the container with the result of remove applied to its
+// contents. The kubeconfig is not changed if it does not exist.
+func removeKubeConfigCredentials(ctx context.Context, target Target, remove func(existing string) (string, error)) error {
+	path, existing, err := ReadKubeConfig(ctx, target.Client, target.Config, target.PodName, target.ContainerName)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(existing) == "" {
+		return nil
+	}
+	kubeconfig, err := remove(existing)
+	if err != nil {
+		return err
+	}
+	return operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, []byte(kubeconfig))
+}
+
+// getKubeConfigPath returns the path of the kubeconfig in the container, which depends on $KUBECONFIG and $HOME.
+func getKubeConfigPath(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName string) (string, error) {
+	stdout, stderr, err := operations.ExecCommandInPod(ctx, client, restconfig, podName, containerName, KubeConfigPathCommand)
+	if err != nil {
+		logging.FromContext(ctx).Debugf("Command stderr: %s", stderr.String())
+		return "", fmt.Errorf("failed to get kubeconfig path: %w", err)
+	}
+	path := strings.TrimSpace(stdout.String())
+	if path == "" {
+		return "", fmt.Errorf("failed to get kubeconfig path: command returned empty path")
+	}
+	return path, nil
+}
+
+// ReadKubeConfig returns the path of the kubeconfig in the container and its contents, or an empty string if it
+// does not exist.
+func ReadKubeConfig(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName string) (path, kubeconfig string, err error) {
+	stdout, stderr, err := operations.ExecCommandInPod(ctx, client, restconfig, podName, containerName, ReadKubeConfigCommand)
+	if err != nil {
+		logging.FromContext(ctx).Debugf("Command stderr: %s", stderr.String())
+		return "", "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	path, kubeconfig, _ = strings.Cut(stdout.String(), "\n")
+	if path == "" {
+		return "", "", fmt.Errorf("failed to read kubeconfig: command returned empty path")
+	}
+	return path, kubeconfig, nil
+}
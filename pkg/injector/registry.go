@@ -0,0 +1,159 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+)
+
+// ReadRegistryAuthCommand prints the path of the containers auth.json in the container on the first line, followed
+// by its contents if it exists. The path is the one used by podman, buildah and skopeo: $REGISTRY_AUTH_FILE if set,
+// otherwise $XDG_RUNTIME_DIR/containers/auth.json, falling back to $XDG_CONFIG_HOME/containers/auth.json if
+// $XDG_RUNTIME_DIR is not set.
+const ReadRegistryAuthCommand = `
+if [ -n "$REGISTRY_AUTH_FILE" ]; then
+	AUTH_FILE="$REGISTRY_AUTH_FILE"
+elif [ -n "$XDG_RUNTIME_DIR" ]; then
+	AUTH_FILE="$XDG_RUNTIME_DIR/containers/auth.json"
+else
+	AUTH_FILE="${XDG_CONFIG_HOME:-$HOME/.config}/containers/auth.json"
+fi
+echo "$AUTH_FILE"
+if [ -f "$AUTH_FILE" ]; then
+	cat "$AUTH_FILE"
+fi
+`
+
+// registryUsername is the username in registry credentials. The OpenShift image registry authenticates requests
+// using the token alone, and ignores the username.
+const registryUsername = "openshift"
+
+// registryAuthInjector adds the user's token to the containers auth.json as the credentials for host, preserving
+// the credentials for other registries.
+type registryAuthInjector struct {
+	host string
+}
+
+func (registryAuthInjector) Name() string {
+	return config.CredentialInjectorRegistryAuth
+}
+
+func (i registryAuthInjector) Inject(ctx context.Context, target Target, request Request) (string, error) {
+	log := logging.FromContext(ctx)
+	path, existing, err := readRegistryAuth(ctx, target)
+	if err != nil {
+		log.Errorf("Failed to read registry credentials in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeCredentialInjectionFailed, "Failed to read registry credentials in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	auth, err := mergeRegistryAuth(existing, i.host, request.Params.BearerToken)
+	if err != nil {
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeCredentialInjectionFailed, "Failed to merge registry credentials in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	if err := operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, auth); err != nil {
+		log.Errorf("Failed to write registry credentials in container %s workspace pod %s: %s", target.ContainerName, target.PodName, err)
+		return "", errors.NewHTTPErrorf(http.StatusInternalServerError, errors.CodeCredentialInjectionFailed, "Failed to write registry credentials in container '%s'", target.ContainerName).WithDetails(err.Error())
+	}
+	return path, nil
+}
+
+// Refresh merges the token into auth.json again, which replaces the previous token for host.
+func (i registryAuthInjector) Refresh(ctx context.Context, target Target, request Request) (string, error) {
+	return i.Inject(ctx, target, request)
+}
+
+func (i registryAuthInjector) Remove(ctx context.Context, target Target, _ Request) error {
+	path, existing, err := readRegistryAuth(ctx, target)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(existing) == "" {
+		return nil
+	}
+	auth, err := removeRegistryAuth(existing, i.host)
+	if err != nil {
+		return err
+	}
+	return operations.WriteFileInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, path, auth)
+}
+
+// readRegistryAuth returns the path of the containers auth.json in the container and its contents, or an empty
+// string if it does not exist.
+func readRegistryAuth(ctx context.Context, target Target) (path, auth string, err error) {
+	stdout, stderr, err := operations.ExecCommandInPod(ctx, target.Client, target.Config, target.PodName, target.ContainerName, ReadRegistryAuthCommand)
+	if err != nil {
+		logging.FromContext(ctx).Debugf("Command stderr: %s", stderr.String())
+		return "", "", fmt.Errorf("failed to read auth.json: %w", err)
+	}
+	path, auth, _ = strings.Cut(stdout.String(), "\n")
+	if path == "" {
+		return "", "", fmt.Errorf("failed to read auth.json: command returned empty path")
+	}
+	return path, auth, nil
+}
+
+// mergeRegistryAuth sets the credentials for host in the existing auth.json to token, and returns the result.
+// Credentials for other registries and other fields in existing are preserved. If existing is empty, a new
+// auth.json is returned.
+func mergeRegistryAuth(existing, host, token string) ([]byte, error) {
+	auth := map[string]interface{}{}
+	if strings.TrimSpace(existing) != "" {
+		if err := json.Unmarshal([]byte(existing), &auth); err != nil {
+			return nil, fmt.Errorf("failed to parse auth.json: %w", err)
+		}
+	}
+	auths := map[string]interface{}{}
+	if auth["auths"] != nil {
+		existingAuths, ok := auth["auths"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failed to parse auth.json: auths is not an object")
+		}
+		auths = existingAuths
+	}
+	auths[host] = map[string]interface{}{
+		"auth": base64.StdEncoding.EncodeToString([]byte(registryUsername + ":" + token)),
+	}
+	auth["auths"] = auths
+	result, err := json.MarshalIndent(auth, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal auth.json: %w", err)
+	}
+	return append(result, '\n'), nil
+}
+
+// removeRegistryAuth removes the credentials for host from the existing auth.json, and returns the result.
+func removeRegistryAuth(existing, host string) ([]byte, error) {
+	auth := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(existing), &auth); err != nil {
+		return nil, fmt.Errorf("failed to parse auth.json: %w", err)
+	}
+	if auth["auths"] != nil {
+		auths, ok := auth["auths"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failed to parse auth.json: auths is not an object")
+		}
+		delete(auths, host)
+	}
+	result, err := json.MarshalIndent(auth, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal auth.json: %w", err)
+	}
+	return append(result, '\n'), nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeRegistryAuth(t *testing.T) {
+	// base64("openshift:test-token")
+	expectedAuth := map[string]interface{}{"auth": "b3BlbnNoaWZ0OnRlc3QtdG9rZW4="}
+
+	result, err := mergeRegistryAuth("", "registry.example.com:5000", "test-token")
+	if assert.NoError(t, err) {
+		var auth map[string]interface{}
+		if assert.NoError(t, json.Unmarshal(result, &auth)) {
+			assert.Equal(t, map[string]interface{}{
+				"auths": map[string]interface{}{"registry.example.com:5000": expectedAuth},
+			}, auth, "Should create auth.json if none exists")
+		}
+	}
+
+	existing := `{
+	"auths": {
+		"quay.io": {"auth": "b3RoZXI="},
+		"registry.example.com:5000": {"auth": "c3RhbGU=", "identitytoken": "stale"}
+	},
+	"credHelpers": {"example.com": "secretservice"}
+}`
+	result, err = mergeRegistryAuth(existing, "registry.example.com:5000", "test-token")
+	if assert.NoError(t, err) {
+		var auth map[string]interface{}
+		if assert.NoError(t, json.Unmarshal(result, &auth)) {
+			assert.Equal(t, map[string]interface{}{
+				"auths": map[string]interface{}{
+					"quay.io":                   map[string]interface{}{"auth": "b3RoZXI="},
+					"registry.example.com:5000": expectedAuth,
+				},
+				"credHelpers": map[string]interface{}{"example.com": "secretservice"},
+			}, auth, "Should replace credentials for registry and preserve others")
+		}
+	}
+
+	_, err = mergeRegistryAuth("not json", "registry.example.com:5000", "test-token")
+	assert.Error(t, err, "Should not overwrite invalid auth.json")
+	_, err = mergeRegistryAuth(`{"auths": []}`, "registry.example.com:5000", "test-token")
+	assert.Error(t, err, "Should not overwrite invalid auth.json")
+}
+
+func TestRemoveRegistryAuth(t *testing.T) {
+	existing := `{
+	"auths": {
+		"quay.io": {"auth": "b3RoZXI="},
+		"registry.example.com:5000": {"auth": "b3BlbnNoaWZ0OnRlc3QtdG9rZW4="}
+	},
+	"credHelpers": {"example.com": "secretservice"}
+}`
+	result, err := removeRegistryAuth(existing, "registry.example.com:5000")
+	if assert.NoError(t, err) {
+		var auth map[string]interface{}
+		if assert.NoError(t, json.Unmarshal(result, &auth)) {
+			assert.Equal(t, map[string]interface{}{
+				"auths":       map[string]interface{}{"quay.io": map[string]interface{}{"auth": "b3RoZXI="}},
+				"credHelpers": map[string]interface{}{"example.com": "secretservice"},
+			}, auth, "Should remove credentials for registry and preserve others")
+		}
+	}
+
+	_, err = removeRegistryAuth("not json", "registry.example.com:5000")
+	assert.Error(t, err, "Should not overwrite invalid auth.json")
+}
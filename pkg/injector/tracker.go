@@ -0,0 +1,161 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Tracker records the credentials injected into containers, so that they can be removed when the user logs out,
+// the workspace is stopped or the server shuts down. It is safe for concurrent use.
+type Tracker struct {
+	mutex sync.Mutex
+	// token is the user's most recent token, which is used to remove credentials outside of a user's request.
+	// It is discarded once no credentials are tracked.
+	token   string
+	tracked []trackedCredential
+}
+
+// trackedCredential is a credential file written by an injector. Credentials are tracked per container, injector
+// and user, as the most recent injection replaces the credentials written by earlier ones.
+type trackedCredential struct {
+	injector      Injector
+	podName       string
+	containerName string
+	path          string
+	request       Request
+}
+
+func (c trackedCredential) sameAs(other trackedCredential) bool {
+	return c.injector.Name() == other.injector.Name() &&
+		c.podName == other.podName &&
+		c.containerName == other.containerName &&
+		c.request.Params.Username == other.request.Params.Username
+}
+
+// NewTracker returns a Tracker that does not track any credentials.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Track records that inj wrote the credentials in request to path in the container in target.
+func (t *Tracker) Track(inj Injector, target Target, path string, request Request) {
+	params := *request.Params
+	request.Params = &params
+	credential := trackedCredential{
+		injector:      inj,
+		podName:       target.PodName,
+		containerName: target.ContainerName,
+		path:          path,
+		request:       request,
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.token = params.BearerToken
+	for idx, tracked := range t.tracked {
+		if tracked.sameAs(credential) {
+			t.tracked[idx] = credential
+			return
+		}
+	}
+	t.tracked = append(t.tracked, credential)
+}
+
+// SetToken replaces the token used by Cleanup, e.g. when the user's credentials are refreshed. It has no effect if
+// no credentials are tracked.
+func (t *Tracker) SetToken(token string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.tracked) > 0 {
+		t.token = token
+	}
+}
+
+// Remove removes all tracked credentials from their containers, using client and restconfig to exec into the
+// containers, and returns the credentials that were removed. Credentials that could not be removed remain tracked,
+// and an error is returned for each of them.
+func (t *Tracker) Remove(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config) ([]api.InjectedCredential, error) {
+	t.mutex.Lock()
+	tracked := append([]trackedCredential(nil), t.tracked...)
+	t.mutex.Unlock()
+
+	var removed []trackedCredential
+	var removeErrs []error
+	for _, credential := range tracked {
+		target := Target{
+			Client:        client,
+			Config:        restconfig,
+			PodName:       credential.podName,
+			ContainerName: credential.containerName,
+		}
+		if err := credential.injector.Remove(ctx, target, credential.request); err != nil {
+			removeErrs = append(removeErrs, fmt.Errorf("failed to remove %s credentials from container %s: %w", credential.injector.Name(), credential.containerName, err))
+			continue
+		}
+		removed = append(removed, credential)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var remaining []trackedCredential
+	for _, credential := range t.tracked {
+		if !containsRequest(removed, credential) {
+			remaining = append(remaining, credential)
+		}
+	}
+	t.tracked = remaining
+	if len(t.tracked) == 0 {
+		t.token = ""
+	}
+
+	var result []api.InjectedCredential
+	for _, credential := range removed {
+		result = append(result, api.InjectedCredential{Injector: credential.injector.Name(), Path: credential.path})
+	}
+	return result, errors.Join(removeErrs...)
+}
+
+// Cleanup removes all tracked credentials from their containers using the user's most recent token. It is intended
+// to be called when the workspace is stopped or the server shuts down, when there is no request from the user.
+func (t *Tracker) Cleanup(ctx context.Context, clientProvider operations.ClientProvider) error {
+	t.mutex.Lock()
+	token := t.token
+	t.mutex.Unlock()
+	if token == "" {
+		return nil
+	}
+	client, restconfig, err := clientProvider.NewClientWithToken(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	_, err = t.Remove(ctx, client, restconfig)
+	return err
+}
+
+// containsRequest returns whether credentials contains credential, as tracked for the same request. Credentials that
+// were tracked again for a newer request while they were being removed are not considered to be removed.
+func containsRequest(credentials []trackedCredential, credential trackedCredential) bool {
+	for _, other := range credentials {
+		if other.sameAs(credential) && other.request.Params == credential.request.Params {
+			return true
+		}
+	}
+	return false
+}
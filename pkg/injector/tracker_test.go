@@ -0,0 +1,91 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package injector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	optest "github.com/redhat-developer/web-terminal-exec/pkg/operations/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	kubeconfig := `apiVersion: v1
+kind: Config
+users:
+- name: test
+  user:
+    token: test-token
+`
+	responseOutputs := map[string]string{
+		ReadKubeConfigCommand:   "/home/user/.kube/config\n" + kubeconfig,
+		ReadRegistryAuthCommand: "/run/user/1000/containers/auth.json\n" + `{"auths": {"registry.example.com": {"auth": "dGVzdA=="}}}`,
+	}
+	cleanup := func(tracker *Tracker, spdy *optest.FakeSPDYExecutorProvider) error {
+		oldSPDYExecutor := operations.NewSPDYExecutor
+		operations.NewSPDYExecutor = spdy.NewFakeSPDYExecutor
+		defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+		return tracker.Cleanup(context.Background(), optest.FakeClientProvider{})
+	}
+	target := Target{PodName: "test-pod", ContainerName: "test-container"}
+	request := func(token string) Request {
+		return Request{Params: &api.InitParams{KubeConfigParams: api.KubeConfigParams{Username: "test", BearerToken: token}}}
+	}
+
+	tracker := NewTracker()
+	spdy := &optest.FakeSPDYExecutorProvider{}
+	assert.NoError(t, cleanup(tracker, spdy))
+	assert.Empty(t, spdy.InputBuffers, "Should not exec into containers if no credentials are tracked")
+
+	tracker.SetToken("ignored-token")
+	tracker.Track(kubeConfigInjector{}, target, "/home/user/.kube/config", request("old-token"))
+	tracker.Track(kubeConfigInjector{}, target, "/home/user/.kube/config", request("test-token"))
+	tracker.Track(registryAuthInjector{host: "registry.example.com"}, target, "/run/user/1000/containers/auth.json", request("test-token"))
+	assert.Len(t, tracker.tracked, 2, "Should replace credentials injected again for the same user")
+	assert.Equal(t, "test-token", tracker.token)
+
+	spdy = &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{
+		ResponseOutputs: responseOutputs,
+		ErrInputs:       []string{"REGISTRY_AUTH_FILE"},
+	}}
+	assert.Error(t, cleanup(tracker, spdy))
+	if assert.Len(t, spdy.InputBuffers, 3) {
+		assert.Equal(t, ReadKubeConfigCommand, spdy.InputBuffers[0])
+		assert.NotContains(t, spdy.InputBuffers[1], "test-token", "Should blank kubeconfig credentials")
+		assert.Equal(t, ReadRegistryAuthCommand, spdy.InputBuffers[2])
+	}
+	assert.Len(t, tracker.tracked, 1, "Should keep tracking credentials that could not be removed")
+	assert.Equal(t, "test-token", tracker.token, "Should keep token while credentials are tracked")
+
+	tracker.SetToken("new-token")
+	spdy = &optest.FakeSPDYExecutorProvider{FakeSPDYExecutor: optest.FakeSPDYExecutor{ResponseOutputs: responseOutputs}}
+	client, restconfig, err := optest.FakeClientProvider{}.NewClientWithToken(context.Background(), "new-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = spdy.NewFakeSPDYExecutor
+	removed, err := tracker.Remove(context.Background(), client, restconfig)
+	operations.NewSPDYExecutor = oldSPDYExecutor
+	if assert.NoError(t, err) {
+		assert.Equal(t, []api.InjectedCredential{
+			{Injector: "registry-auth", Path: "/run/user/1000/containers/auth.json"},
+		}, removed)
+	}
+	if assert.Len(t, spdy.InputBuffers, 2) {
+		assert.JSONEq(t, `{"auths": {}}`, spdy.InputBuffers[1], "Should remove registry credentials")
+	}
+	assert.Empty(t, tracker.tracked)
+	assert.Empty(t, tracker.token, "Should discard token once no credentials are tracked")
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package lifecycle runs the HTTP server and shuts it down gracefully, along with other components
+// of the server, when the process receives SIGTERM or SIGINT.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes returned by Manager.Run
+const (
+	// ExitCodeSuccess indicates that the server was shut down gracefully
+	ExitCodeSuccess = 0
+	// ExitCodeServerError indicates that the server failed to start or stopped unexpectedly
+	ExitCodeServerError = 1
+	// ExitCodeShutdownError indicates that the server was shut down, but not all components shut down
+	// gracefully within the grace period
+	ExitCodeShutdownError = 2
+)
+
+// shutdownHook is called to shut down a component of the server. Hooks should return once the component
+// is shut down, or once ctx is done.
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager coordinates graceful shutdown of the HTTP server and other components.
+type Manager struct {
+	server      *http.Server
+	gracePeriod time.Duration
+	hookTimeout time.Duration
+	hooks       []shutdownHook
+	// baseCtx is the parent context of all requests served by server. It is cancelled if in-flight
+	// requests do not complete within the grace period.
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+}
+
+// NewManager returns a Manager for server. On shutdown, in-flight requests are given gracePeriod to complete,
+// and each shutdown hook is then given hookTimeout, so that hooks can run even if in-flight requests use up
+// the grace period.
+func NewManager(server *http.Server, gracePeriod, hookTimeout time.Duration) *Manager {
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	server.BaseContext = func(net.Listener) context.Context {
+		return baseCtx
+	}
+	return &Manager{
+		server:      server,
+		gracePeriod: gracePeriod,
+		hookTimeout: hookTimeout,
+		baseCtx:     baseCtx,
+		cancelBase:  cancelBase,
+	}
+}
+
+// OnShutdown registers fn to be called once the HTTP server has stopped serving requests. Hooks are called
+// in the order they are registered, each with its own deadline.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run calls serve, which should start the HTTP server (e.g. server.ListenAndServeTLS), and blocks until
+// ctx is done, the process receives SIGTERM or SIGINT, or the server fails. The server is then shut down
+// and Run returns an exit code for the process.
+func (m *Manager) Run(ctx context.Context, serve func() error) int {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- serve()
+	}()
+
+	exitCode := ExitCodeSuccess
+	select {
+	case err := <-serveErr:
+		logrus.Errorf("Server stopped unexpectedly: %s", err)
+		exitCode = ExitCodeServerError
+	case <-ctx.Done():
+		logrus.Info("Shutting down server")
+	}
+	// Stop handling signals so that a second signal terminates the process immediately
+	stop()
+
+	if err := m.shutdown(); err != nil {
+		logrus.Errorf("Failed to shut down gracefully: %s", err)
+		if exitCode == ExitCodeSuccess {
+			exitCode = ExitCodeShutdownError
+		}
+	} else {
+		logrus.Info("Server shut down")
+	}
+	return exitCode
+}
+
+func (m *Manager) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.gracePeriod)
+	defer cancel()
+	defer m.cancelBase()
+
+	var shutdownErrs []error
+	if err := m.server.Shutdown(ctx); err != nil {
+		// Cancel in-flight requests (e.g. exec calls) that did not complete within the grace period
+		m.cancelBase()
+		if closeErr := m.server.Close(); closeErr != nil {
+			logrus.Debugf("Failed to close server: %s", closeErr)
+		}
+		shutdownErrs = append(shutdownErrs, fmt.Errorf("failed to drain in-flight requests: %w", err))
+	}
+	for _, hook := range m.hooks {
+		logrus.Debugf("Shutting down %s", hook.name)
+		if err := m.runHook(hook); err != nil {
+			shutdownErrs = append(shutdownErrs, fmt.Errorf("failed to shut down %s: %w", hook.name, err))
+		}
+	}
+	return errors.Join(shutdownErrs...)
+}
+
+func (m *Manager) runHook(hook shutdownHook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.hookTimeout)
+	defer cancel()
+	return hook.fn(ctx)
+}
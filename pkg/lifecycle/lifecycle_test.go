@@ -0,0 +1,131 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownOnContextDone(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	server := &http.Server{Handler: http.NotFoundHandler()}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	manager := NewManager(server, time.Second, time.Second)
+	var calls []string
+	for _, name := range []string{"first", "second"} {
+		manager.OnShutdown(name, func(context.Context) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exitCode := manager.Run(ctx, func() error {
+		return server.Serve(listener)
+	})
+	assert.Equal(t, ExitCodeSuccess, exitCode)
+	assert.Equal(t, []string{"first", "second"}, calls, "Shutdown hooks should be called in order")
+}
+
+func TestShutdownOnServerError(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	server := &http.Server{Handler: http.NotFoundHandler()}
+	manager := NewManager(server, time.Second, time.Second)
+	hookCalled := false
+	manager.OnShutdown("test", func(context.Context) error {
+		hookCalled = true
+		return nil
+	})
+
+	exitCode := manager.Run(context.Background(), func() error {
+		return fmt.Errorf("failed to load certificate")
+	})
+	assert.Equal(t, ExitCodeServerError, exitCode)
+	assert.True(t, hookCalled, "Shutdown hooks should be called if the server fails")
+}
+
+func TestShutdownHookError(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	server := &http.Server{Handler: http.NotFoundHandler()}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	manager := NewManager(server, time.Second, time.Second)
+	manager.OnShutdown("test", func(context.Context) error {
+		return fmt.Errorf("test error")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exitCode := manager.Run(ctx, func() error {
+		return server.Serve(listener)
+	})
+	assert.Equal(t, ExitCodeShutdownError, exitCode)
+}
+
+func TestShutdownCancelsInFlightRequests(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-r.Context().Done()
+			close(cancelled)
+		}),
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	manager := NewManager(server, 50*time.Millisecond, time.Second)
+	hookCtxErr := make(chan error, 1)
+	manager.OnShutdown("test", func(ctx context.Context) error {
+		hookCtxErr <- ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/", listener.Addr()))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	go func() {
+		<-started
+		cancel()
+	}()
+	exitCode := manager.Run(ctx, func() error {
+		return server.Serve(listener)
+	})
+	assert.Equal(t, ExitCodeShutdownError, exitCode, "Should report failure if requests are not drained within grace period")
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("In-flight request should be cancelled once grace period expires")
+	}
+	assert.NoError(t, <-hookCtxErr, "Hooks should have their own deadline once the grace period has expired")
+}
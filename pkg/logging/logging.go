@@ -0,0 +1,69 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package logging carries request-scoped loggers through contexts, so that log lines produced while
+// handling a request can be correlated by request ID.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const requestIDField = "requestId"
+
+// Request IDs provided by clients are only accepted if they match this expression, to avoid
+// injecting arbitrary content into logs and response headers.
+var validRequestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+type loggerKey struct{}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		// crypto/rand does not fail on supported platforms; an empty ID is logged as missing
+		logrus.Errorf("Failed to generate request ID: %s", err)
+		return ""
+	}
+	return hex.EncodeToString(idBytes)
+}
+
+// IsValidRequestID returns whether id can be used as a request ID.
+func IsValidRequestID(id string) bool {
+	return validRequestIDRegexp.MatchString(id)
+}
+
+// WithRequestID returns a copy of ctx that carries requestID and a logger that includes it in all entries.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return context.WithValue(ctx, loggerKey{}, logrus.WithField(requestIDField, requestID))
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string if there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the logger carried by ctx. If ctx does not carry a logger, an entry for the
+// standard logger is returned.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return logger
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package logging
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextLogger(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, RequestID(ctx))
+	assert.NotContains(t, FromContext(ctx).Data, requestIDField, "Should fall back to standard logger")
+
+	ctx = WithRequestID(ctx, "test-request-id")
+	assert.Equal(t, "test-request-id", RequestID(ctx))
+	assert.Equal(t, "test-request-id", FromContext(ctx).Data[requestIDField])
+}
+
+func TestRequestIDValidation(t *testing.T) {
+	assert.True(t, IsValidRequestID(NewRequestID()), "Generated request IDs should be valid")
+	assert.NotEqual(t, NewRequestID(), NewRequestID(), "Generated request IDs should be unique")
+	assert.True(t, IsValidRequestID("8b3b7c1e-2c5d-4f0a-9f3e-7f1d2a6b9c01"))
+	assert.False(t, IsValidRequestID(""))
+	assert.False(t, IsValidRequestID("id\nwith newline"))
+	assert.False(t, IsValidRequestID(strings.Repeat("a", 129)))
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+// Package metrics defines the Prometheus metrics exposed by the Web Terminal Exec server.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "web_terminal_exec"
+
+// Stages of an /exec/init request that may fail.
+const (
+	ExecInitStagePodLookup        = "pod_lookup"
+	ExecInitStageContainerSelect  = "container_select"
+	ExecInitStageKubeconfigWrite  = "kubeconfig_write"
+	ExecInitStageCredentialInject = "credential_inject"
+	ExecInitStageShellDetect      = "shell_detect"
+)
+
+// Results of looking up a token in the authentication cache.
+const (
+	AuthCacheResultHit         = "hit"
+	AuthCacheResultNegativeHit = "negative_hit"
+	AuthCacheResultMiss        = "miss"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled, by endpoint, method and status code.",
+		},
+		[]string{"endpoint", "method", "code"},
+	)
+
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests, by endpoint, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"endpoint", "method", "code"},
+	)
+
+	execInitFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "exec_init_failures_total",
+			Help:      "Number of failed /exec/init requests, by the stage that failed.",
+		},
+		[]string{"stage"},
+	)
+
+	authFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_failures_total",
+			Help:      "Number of requests rejected because the user could not be authenticated.",
+		},
+	)
+
+	authCacheLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_cache_lookups_total",
+			Help:      "Number of lookups in the authentication cache, by result.",
+		},
+		[]string{"result"},
+	)
+
+	authCacheEvictionsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_cache_evictions_total",
+			Help:      "Number of entries evicted from the authentication cache because it was full.",
+		},
+	)
+
+	authCacheEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "auth_cache_entries",
+			Help:      "Number of entries in the authentication cache.",
+		},
+	)
+
+	idleTimerResetsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "idle_timer_resets_total",
+			Help:      "Number of times the idle timer was reset due to user activity.",
+		},
+	)
+
+	workspaceStopAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_stop_attempts_total",
+			Help:      "Number of attempts to stop the workspace due to inactivity, by result.",
+		},
+		[]string{"result"},
+	)
+
+	certificateReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "certificate_reloads_total",
+			Help:      "Number of attempts to reload the TLS certificate after it changed on disk, by result.",
+		},
+		[]string{"result"},
+	)
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		execInitFailuresTotal,
+		authFailuresTotal,
+		authCacheLookupsTotal,
+		authCacheEvictionsTotal,
+		authCacheEntries,
+		idleTimerResetsTotal,
+		workspaceStopAttemptsTotal,
+		certificateReloadsTotal,
+	)
+	// Initialize labelled metrics so that they are exported before the first event occurs
+	for _, stage := range []string{ExecInitStagePodLookup, ExecInitStageContainerSelect, ExecInitStageKubeconfigWrite, ExecInitStageCredentialInject, ExecInitStageShellDetect} {
+		execInitFailuresTotal.WithLabelValues(stage)
+	}
+	for _, result := range []string{AuthCacheResultHit, AuthCacheResultNegativeHit, AuthCacheResultMiss} {
+		authCacheLookupsTotal.WithLabelValues(result)
+	}
+	workspaceStopAttemptsTotal.WithLabelValues(resultSuccess)
+	workspaceStopAttemptsTotal.WithLabelValues(resultFailure)
+	certificateReloadsTotal.WithLabelValues(resultSuccess)
+	certificateReloadsTotal.WithLabelValues(resultFailure)
+}
+
+// Handler returns an http.Handler that serves metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a handled HTTP request. Endpoint should be the route pattern rather than the
+// request path, to avoid creating a series per session ID.
+func ObserveRequest(endpoint, method string, code int, duration time.Duration) {
+	codeLabel := strconv.Itoa(code)
+	httpRequestsTotal.WithLabelValues(endpoint, method, codeLabel).Inc()
+	httpRequestDuration.WithLabelValues(endpoint, method, codeLabel).Observe(duration.Seconds())
+}
+
+// ExecInitFailed records an /exec/init request that failed at stage.
+func ExecInitFailed(stage string) {
+	execInitFailuresTotal.WithLabelValues(stage).Inc()
+}
+
+// AuthFailed records a request that was rejected because the user could not be authenticated.
+func AuthFailed() {
+	authFailuresTotal.Inc()
+}
+
+// AuthCacheLookup records a lookup in the authentication cache with the given result.
+func AuthCacheLookup(result string) {
+	authCacheLookupsTotal.WithLabelValues(result).Inc()
+}
+
+// AuthCacheEvicted records an entry being evicted from the authentication cache because it was full.
+func AuthCacheEvicted() {
+	authCacheEvictionsTotal.Inc()
+}
+
+// SetAuthCacheEntries records the number of entries in the authentication cache.
+func SetAuthCacheEntries(entries int) {
+	authCacheEntries.Set(float64(entries))
+}
+
+// IdleTimerReset records a reset of the idle timer.
+func IdleTimerReset() {
+	idleTimerResetsTotal.Inc()
+}
+
+// WorkspaceStopAttempted records an attempt to stop the workspace; err is the result of the attempt.
+func WorkspaceStopAttempted(err error) {
+	if err != nil {
+		workspaceStopAttemptsTotal.WithLabelValues(resultFailure).Inc()
+	} else {
+		workspaceStopAttemptsTotal.WithLabelValues(resultSuccess).Inc()
+	}
+}
+
+// CertificateReloaded records an attempt to reload the TLS certificate; err is the result of the attempt.
+func CertificateReloaded(err error) {
+	if err != nil {
+		certificateReloadsTotal.WithLabelValues(resultFailure).Inc()
+	} else {
+		certificateReloadsTotal.WithLabelValues(resultSuccess).Inc()
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveRequest(t *testing.T) {
+	counter := httpRequestsTotal.WithLabelValues("/test", "GET", "404")
+	before := testutil.ToFloat64(counter)
+	ObserveRequest("/test", "GET", 404, 10*time.Millisecond)
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	assert.Equal(t, 1, testutil.CollectAndCount(httpRequestDuration, "web_terminal_exec_http_request_duration_seconds"))
+}
+
+func TestExecInitFailed(t *testing.T) {
+	counter := execInitFailuresTotal.WithLabelValues(ExecInitStageShellDetect)
+	before := testutil.ToFloat64(counter)
+	ExecInitFailed(ExecInitStageShellDetect)
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestWorkspaceStopAttempted(t *testing.T) {
+	success := workspaceStopAttemptsTotal.WithLabelValues(resultSuccess)
+	failure := workspaceStopAttemptsTotal.WithLabelValues(resultFailure)
+	successBefore, failureBefore := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+	WorkspaceStopAttempted(nil)
+	WorkspaceStopAttempted(fmt.Errorf("test error"))
+	WorkspaceStopAttempted(fmt.Errorf("test error"))
+	assert.Equal(t, successBefore+1, testutil.ToFloat64(success))
+	assert.Equal(t, failureBefore+2, testutil.ToFloat64(failure))
+}
+
+func TestCertificateReloaded(t *testing.T) {
+	success := certificateReloadsTotal.WithLabelValues(resultSuccess)
+	failure := certificateReloadsTotal.WithLabelValues(resultFailure)
+	successBefore, failureBefore := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+	CertificateReloaded(nil)
+	CertificateReloaded(fmt.Errorf("test error"))
+	assert.Equal(t, successBefore+1, testutil.ToFloat64(success))
+	assert.Equal(t, failureBefore+1, testutil.ToFloat64(failure))
+}
@@ -11,6 +11,7 @@
 package operations
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 
@@ -37,12 +38,6 @@ var (
 		Group:   "user.openshift.io",
 		Version: "v1",
 	}
-
-	userGVR = schema.GroupVersionResource{
-		Group:    "user.openshift.io",
-		Version:  "v1",
-		Resource: "users",
-	}
 )
 
 // NewSPDYExecutor is the function used to create a new SPDY Executor, with default
@@ -50,10 +45,14 @@ var (
 // the operations package.
 var NewSPDYExecutor = remotecommand.NewSPDYExecutor
 
+// ClientProvider creates clients for the Kubernetes API. Clients are not bound to ctx; it is only used to
+// abandon creating a client if the caller is no longer waiting for it.
 type ClientProvider interface {
-	NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error)
-	NewClientWithToken(token string) (kubernetes.Interface, *rest.Config, error)
-	NewOpenShiftUserClient(token string) (dynamic.Interface, *rest.Config, error)
+	NewDevWorkspaceClient(ctx context.Context) (dynamic.Interface, *rest.Config, error)
+	NewClientWithToken(ctx context.Context, token string) (kubernetes.Interface, *rest.Config, error)
+	NewOpenShiftUserClient(ctx context.Context, token string) (dynamic.Interface, *rest.Config, error)
+	// NewServiceAccountClient returns a client that uses the server's own service account credentials
+	NewServiceAccountClient(ctx context.Context) (kubernetes.Interface, *rest.Config, error)
 }
 
 type defaultClientProvider struct {
@@ -66,8 +65,8 @@ func DefaultClientProvider() ClientProvider {
 	}
 }
 
-func (defaultClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error) {
-	config, err := rest.InClusterConfig()
+func (defaultClientProvider) NewDevWorkspaceClient(ctx context.Context) (dynamic.Interface, *rest.Config, error) {
+	config, err := inClusterConfig(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -82,11 +81,11 @@ func (defaultClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.C
 	return client, config, nil
 }
 
-func (defaultClientProvider) NewClientWithToken(token string) (kubernetes.Interface, *rest.Config, error) {
+func (defaultClientProvider) NewClientWithToken(ctx context.Context, token string) (kubernetes.Interface, *rest.Config, error) {
 	if len(token) == 0 {
 		return nil, nil, fmt.Errorf("failed to create client -- token must not be empty")
 	}
-	config, err := rest.InClusterConfig()
+	config, err := inClusterConfig(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,11 +100,11 @@ func (defaultClientProvider) NewClientWithToken(token string) (kubernetes.Interf
 	return client, config, nil
 }
 
-func (defaultClientProvider) NewOpenShiftUserClient(token string) (dynamic.Interface, *rest.Config, error) {
+func (defaultClientProvider) NewOpenShiftUserClient(ctx context.Context, token string) (dynamic.Interface, *rest.Config, error) {
 	if len(token) == 0 {
 		return nil, nil, fmt.Errorf("failed to create client -- token must not be empty")
 	}
-	config, err := rest.InClusterConfig()
+	config, err := inClusterConfig(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -121,3 +120,24 @@ func (defaultClientProvider) NewOpenShiftUserClient(token string) (dynamic.Inter
 	}
 	return client, config, nil
 }
+
+func (defaultClientProvider) NewServiceAccountClient(ctx context.Context) (kubernetes.Interface, *rest.Config, error) {
+	config, err := inClusterConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, config, nil
+}
+
+// inClusterConfig returns the in-cluster client configuration, unless ctx is already done.
+func inClusterConfig(ctx context.Context) (*rest.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return rest.InClusterConfig()
+}
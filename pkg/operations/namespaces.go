@@ -0,0 +1,89 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var projectGVR = schema.GroupVersionResource{
+	Group:    "project.openshift.io",
+	Version:  "v1",
+	Resource: "projects",
+}
+
+// namespaceListPageSize is the number of projects or namespaces requested at a time
+const namespaceListPageSize = 500
+
+// ListUserNamespaces returns the sorted names of all namespaces the user can access. On OpenShift, these are the
+// user's projects, listed with projectClient. If the OpenShift Project API is not available, all namespaces are
+// listed with client, which requires the user to be allowed to list namespaces.
+func ListUserNamespaces(ctx context.Context, projectClient dynamic.Interface, client kubernetes.Interface) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.NamespaceLookupTimeout)
+	defer cancel()
+
+	names, err := listProjectNames(ctx, projectClient)
+	if k8serrors.IsNotFound(err) {
+		names, err = listNamespaceNames(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listProjectNames returns the names of the user's OpenShift projects, requesting them in pages.
+func listProjectNames(ctx context.Context, projectClient dynamic.Interface) ([]string, error) {
+	var names []string
+	listOptions := metav1.ListOptions{Limit: namespaceListPageSize}
+	for {
+		projects, err := projectClient.Resource(projectGVR).List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects.Items {
+			names = append(names, project.GetName())
+		}
+		if listOptions.Continue = projects.GetContinue(); listOptions.Continue == "" {
+			return names, nil
+		}
+	}
+}
+
+// listNamespaceNames returns the names of all namespaces, requesting them in pages.
+func listNamespaceNames(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+	var names []string
+	listOptions := metav1.ListOptions{Limit: namespaceListPageSize}
+	for {
+		namespaces, err := client.CoreV1().Namespaces().List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range namespaces.Items {
+			names = append(names, namespace.Name)
+		}
+		if listOptions.Continue = namespaces.Continue; listOptions.Continue == "" {
+			return names, nil
+		}
+	}
+}
@@ -13,12 +13,14 @@ package operations
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/config"
-	authenticationv1 "k8s.io/api/authentication/v1"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,7 +33,7 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 )
 
-func StopDevWorkspace(devworkspaceClient dynamic.Interface) error {
+func StopDevWorkspace(ctx context.Context, devworkspaceClient dynamic.Interface) error {
 	stopWorkspacePatch := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"metadata": map[string]interface{}{
@@ -48,7 +50,9 @@ func StopDevWorkspace(devworkspaceClient dynamic.Interface) error {
 	if err != nil {
 		return err
 	}
-	_, err = devworkspaceClient.Resource(devworkspaceGVR).Namespace(config.DevWorkspaceNamespace).Patch(context.TODO(), config.DevWorkspaceName, types.MergePatchType, patchJSON, v1.PatchOptions{})
+	ctx, cancel := context.WithTimeout(ctx, constants.WorkspaceStopTimeout)
+	defer cancel()
+	_, err = devworkspaceClient.Resource(devworkspaceGVR).Namespace(config.DevWorkspaceNamespace).Patch(ctx, config.DevWorkspaceName, types.MergePatchType, patchJSON, v1.PatchOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to patch DevWorkspace: %s", err)
 	}
@@ -56,7 +60,44 @@ func StopDevWorkspace(devworkspaceClient dynamic.Interface) error {
 	return nil
 }
 
-func ExecCommandInPod(client kubernetes.Interface, restconfig *rest.Config, podName, containerName, command string) (stdout, stderr *bytes.Buffer, err error) {
+// ExecCommandInPod runs command in the specified container using /bin/sh, returning its output once it exits.
+// The command is cancelled if ctx is done or it does not complete within constants.ExecCommandTimeout.
+func ExecCommandInPod(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName, command string) (stdout, stderr *bytes.Buffer, err error) {
+	return execInPod(ctx, client, restconfig, podName, containerName, []string{"/bin/sh"}, strings.NewReader(command))
+}
+
+// writeFileScript writes stdin to the path in its first argument. The file is written to a temporary file in the
+// same directory that is renamed over path, so that path is never partially written.
+const writeFileScript = `
+set -e
+umask 077
+dir="$(dirname "$1")"
+mkdir -p "$dir"
+tmp="$(mktemp "$dir/.$(basename "$1").XXXXXX")"
+trap 'rm -f "$tmp"' EXIT
+cat > "$tmp"
+chmod 600 "$tmp"
+mv -f "$tmp" "$1"
+`
+
+// WriteFileInPod writes content to path in the specified container with 0600 permissions, atomically replacing
+// any existing file and creating parent directories as needed. The content is streamed over stdin to a fixed
+// script, so it is never interpreted by the shell or included in the command, logs or returned errors.
+// The same timeout as ExecCommandInPod applies.
+func WriteFileInPod(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName, path string, content []byte) error {
+	_, stderr, err := execInPod(ctx, client, restconfig, podName, containerName, []string{"/bin/sh", "-c", writeFileScript, "sh", path}, bytes.NewReader(content))
+	if err != nil {
+		if stderr != nil && strings.TrimSpace(stderr.String()) != "" {
+			return fmt.Errorf("failed to write %s: %s: %s", path, err, strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("failed to write %s: %s", path, err)
+	}
+	return nil
+}
+
+func execInPod(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName string, command []string, stdin io.Reader) (stdout, stderr *bytes.Buffer, err error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.ExecCommandTimeout)
+	defer cancel()
 	req := client.CoreV1().RESTClient().
 		Post().
 		Namespace(config.DevWorkspaceNamespace).
@@ -65,7 +106,7 @@ func ExecCommandInPod(client kubernetes.Interface, restconfig *rest.Config, podN
 		SubResource("exec").
 		VersionedParams(&corev1.PodExecOptions{
 			Container: containerName,
-			Command:   []string{"/bin/sh"},
+			Command:   command,
 			Stdout:    true,
 			Stderr:    true,
 			Stdin:     true,
@@ -77,10 +118,9 @@ func ExecCommandInPod(client kubernetes.Interface, restconfig *rest.Config, podN
 		return nil, nil, fmt.Errorf("error setting up executor for command: %s", err)
 	}
 
-	input := strings.NewReader(command)
 	var outBuf, errBuf bytes.Buffer
-	if err := executor.Stream(remotecommand.StreamOptions{
-		Stdin:  input,
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
 		Stdout: &outBuf,
 		Stderr: &errBuf,
 	}); err != nil {
@@ -89,85 +129,100 @@ func ExecCommandInPod(client kubernetes.Interface, restconfig *rest.Config, podN
 	return &outBuf, &errBuf, nil
 }
 
-func GetCurrentWorkspacePod(client kubernetes.Interface) (*corev1.Pod, error) {
-	filterOptions := metav1.ListOptions{LabelSelector: config.PodSelector, FieldSelector: "status.phase=Running"}
-	podList, err := client.CoreV1().Pods(config.DevWorkspaceNamespace).List(context.TODO(), filterOptions)
+// ExecInteractiveInPod starts cmd in the specified container with a TTY attached, streaming stdin and stdout
+// until the command exits or ctx is cancelled. Terminal sizes received on resize are applied to the TTY;
+// the channel should be closed by the caller once no further resize events will be sent.
+func ExecInteractiveInPod(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName string, cmd []string, stdin io.Reader, stdout io.Writer, resize <-chan remotecommand.TerminalSize) error {
+	req := client.CoreV1().RESTClient().
+		Post().
+		Namespace(config.DevWorkspaceNamespace).
+		Resource("pods").
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   cmd,
+			Stdout:    true,
+			Stderr:    false,
+			Stdin:     true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	executor, err := NewSPDYExecutor(restconfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("error setting up executor for command: %s", err)
+	}
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Tty:               true,
+		TerminalSizeQueue: terminalSizeQueue(resize),
+	}); err != nil {
+		return fmt.Errorf("error streaming command in container: %s", err)
+	}
+	return nil
+}
+
+// terminalSizeQueue implements remotecommand.TerminalSizeQueue for a channel of terminal sizes
+type terminalSizeQueue <-chan remotecommand.TerminalSize
+
+var _ remotecommand.TerminalSizeQueue = (terminalSizeQueue)(nil)
+
+// Next blocks until a valid terminal size is available, returning nil once the channel is closed.
+// Sizes with a zero width or height are ignored, as they cannot be applied to a TTY.
+func (q terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	for size := range q {
+		if size.Width == 0 || size.Height == 0 {
+			continue
+		}
+		return &size
+	}
+	return nil
+}
+
+var (
+	// ErrWorkspacePodNotFound is returned by GetCurrentWorkspacePod if no pods match the workspace's pod selector
+	ErrWorkspacePodNotFound = errors.New("no workspace pods found")
+	// ErrWorkspacePodNotRunning is returned by GetCurrentWorkspacePod if workspace pods exist but none are running
+	ErrWorkspacePodNotRunning = errors.New("workspace pod is not running")
+)
+
+func GetCurrentWorkspacePod(ctx context.Context, client kubernetes.Interface) (*corev1.Pod, error) {
+	// Pods are filtered by phase here rather than with a field selector to distinguish between a workspace
+	// that is not running and one that does not exist.
+	ctx, cancel := context.WithTimeout(ctx, constants.PodLookupTimeout)
+	defer cancel()
+	filterOptions := metav1.ListOptions{LabelSelector: config.PodSelector}
+	podList, err := client.CoreV1().Pods(config.DevWorkspaceNamespace).List(ctx, filterOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods in namespace '%s': %s", config.DevWorkspaceNamespace, err)
 	}
-	switch len(podList.Items) {
+	var runningPods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			runningPods = append(runningPods, pod)
+		}
+	}
+	switch len(runningPods) {
 	case 0:
-		return nil, fmt.Errorf("no workspace pods found in namespace '%s'", config.DevWorkspaceNamespace)
+		if len(podList.Items) > 0 {
+			return nil, fmt.Errorf("%w: pod '%s' in namespace '%s' is in phase %s", ErrWorkspacePodNotRunning, podList.Items[0].Name, config.DevWorkspaceNamespace, podList.Items[0].Status.Phase)
+		}
+		return nil, fmt.Errorf("%w in namespace '%s'", ErrWorkspacePodNotFound, config.DevWorkspaceNamespace)
 	case 1:
-		return &podList.Items[0], nil
+		return &runningPods[0], nil
 	default:
 		// Multiple pods found -- try to get pod that exec is running in; may occur if dedicated pods are used
 		// Workaround as current pod name is not available -- hostname is substitute
 		podName := os.Getenv("HOSTNAME")
 		if podName == "" {
-			return &podList.Items[0], nil
+			return &runningPods[0], nil
 		}
-		for idx, pod := range podList.Items {
+		for idx, pod := range runningPods {
 			if pod.Name == podName {
-				return &podList.Items[idx], nil
+				return &runningPods[idx], nil
 			}
 		}
 		return nil, fmt.Errorf("failed to get current workspace pod")
 	}
 }
-
-func GetCurrentUserUID(token string, clientProvider ClientProvider) (string, error) {
-	uid, err := getCurrentUserUIDFromOpenShiftUserAPI(token, clientProvider)
-	if err == nil {
-		return uid, nil
-	}
-
-	// Fall back to SelfSubjectReview on clusters where the OpenShift User API is unavailable
-	// (e.g. BYO external authentication without user.openshift.io).
-	uid, fallbackErr := getCurrentUserUIDFromSelfSubjectReview(token, clientProvider)
-	if fallbackErr == nil {
-		return uid, nil
-	}
-
-	return "", fmt.Errorf(
-		"failed to get current user information: OpenShift User API error: %w; SelfSubjectReview error: %w",
-		err,
-		fallbackErr,
-	)
-}
-
-func getCurrentUserUIDFromSelfSubjectReview(token string, clientProvider ClientProvider) (string, error) {
-	client, _, err := clientProvider.NewClientWithToken(token)
-	if err != nil {
-		return "", fmt.Errorf("failed to create client to check user info: %w", err)
-	}
-	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(
-		context.Background(),
-		&authenticationv1.SelfSubjectReview{},
-		metav1.CreateOptions{},
-	)
-	if err != nil {
-		return "", err
-	}
-
-	if review.Status.UserInfo.UID == "" {
-		return "", fmt.Errorf("SelfSubjectReview returned empty UID")
-	}
-
-	return review.Status.UserInfo.UID, nil
-}
-
-func getCurrentUserUIDFromOpenShiftUserAPI(token string, clientProvider ClientProvider) (string, error) {
-	userClient, _, err := clientProvider.NewOpenShiftUserClient(token)
-	if err != nil {
-		return "", err
-	}
-	userInfo, err := userClient.Resource(userGVR).Namespace("").Get(context.Background(), "~", metav1.GetOptions{})
-	if err != nil {
-		return "", err
-	}
-
-	// kube:admin / kubeadmin have no Kubernetes UID; empty string is a valid identifier
-	// when AUTHENTICATED_USER_ID is also empty (see config.AuthenticatedUserID).
-	return string(userInfo.GetUID()), nil
-}
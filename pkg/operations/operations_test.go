@@ -12,162 +12,34 @@ package operations
 
 import (
 	"context"
-	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/stretchr/testify/assert"
-	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/remotecommand"
 	"sigs.k8s.io/yaml"
 )
 
-func TestGetCurrentUserUID(t *testing.T) {
-	const expectedUID = "test-user-uid"
-	tests := []struct {
-		name        string
-		provider    ClientProvider
-		errRegexp   string
-		expectedUID string
-	}{
-		{
-			name: "Should return UID from OpenShift User API",
-			provider: testUserIDClientProvider{
-				userAPIUID: expectedUID,
-			},
-			expectedUID: expectedUID,
-		},
-		{
-			name: "Should fall back to SelfSubjectReview when OpenShift User API is unavailable",
-			provider: testUserIDClientProvider{
-				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
-				userUID:            expectedUID,
-			},
-			expectedUID: expectedUID,
-		},
-		{
-			name: "Should return error when client creation fails",
-			provider: testUserIDClientProvider{
-				returnClientError: true,
-			},
-			errRegexp: "failed to create client to check user info",
-		},
-		{
-			name: "Should return error when both user lookups fail",
-			provider: testUserIDClientProvider{
-				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
-				returnReviewError:  apierrors.NewNotFound(schema.GroupResource{Group: "authentication.k8s.io", Resource: "selfsubjectreviews"}, "self"),
-			},
-			errRegexp: "failed to get current user information",
-		},
-		{
-			name: "Should allow empty UID from OpenShift User API for kube:admin",
-			provider: testUserIDClientProvider{
-				userAPIUID:      "",
-				emptyUserAPIUID: true,
-			},
-			expectedUID: "",
-		},
-		{
-			name: "Should return error when SelfSubjectReview returns empty UID on fallback",
-			provider: testUserIDClientProvider{
-				returnUserAPIError: apierrors.NewNotFound(schema.GroupResource{Group: "user.openshift.io", Resource: "users"}, "~"),
-				userUID:            "",
-			},
-			errRegexp: "SelfSubjectReview returned empty UID",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			uid, err := GetCurrentUserUID("test-token", tt.provider)
-			if tt.errRegexp != "" {
-				assert.Error(t, err)
-				assert.Regexp(t, tt.errRegexp, err.Error())
-				if tt.name == "Should return error when both user lookups fail" {
-					assert.Contains(t, err.Error(), "OpenShift User API error:")
-					assert.Contains(t, err.Error(), "SelfSubjectReview error:")
-				}
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedUID, uid)
-		})
-	}
-}
-
-type testUserIDClientProvider struct {
-	userUID            string
-	userAPIUID         string
-	returnClientError  bool
-	returnReviewError  error
-	returnUserAPIError error
-	emptyUserAPIUID    bool
-}
-
-func (p testUserIDClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error) {
-	return nil, nil, nil
-}
-
-func (p testUserIDClientProvider) NewClientWithToken(string) (kubernetes.Interface, *rest.Config, error) {
-	if p.returnClientError {
-		return nil, nil, fmt.Errorf("(TEST) expected error")
-	}
-	client := fake.NewSimpleClientset()
-	client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
-		if p.returnReviewError != nil {
-			return true, nil, p.returnReviewError
-		}
-		return true, &authenticationv1.SelfSubjectReview{
-			Status: authenticationv1.SelfSubjectReviewStatus{
-				UserInfo: authenticationv1.UserInfo{
-					UID: p.userUID,
-				},
-			},
-		}, nil
-	})
-	return client, &rest.Config{}, nil
-}
-
-func (p testUserIDClientProvider) NewOpenShiftUserClient(string) (dynamic.Interface, *rest.Config, error) {
-	if p.returnUserAPIError != nil {
-		return fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}), &rest.Config{}, nil
-	}
-	if p.userAPIUID == "" && !p.emptyUserAPIUID {
-		return nil, nil, fmt.Errorf("(TEST) OpenShift User API not configured")
-	}
-	fakeUser := &unstructured.Unstructured{
-		Object: map[string]interface{}{
-			"metadata": map[string]interface{}{
-				"name": "~",
-				"uid":  p.userAPIUID,
-			},
-		},
-	}
-	fakeUser.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "user.openshift.io",
-		Version: "v1",
-		Kind:    "User",
-	})
-	client := fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}, fakeUser)
-	return client, &rest.Config{}, nil
-}
-
 func setConfigForTest() {
 	config.DevWorkspaceName = "test-workspace"
 	config.DevWorkspaceNamespace = "test-namespace"
@@ -191,7 +63,7 @@ func TestStopDevWorkspace(t *testing.T) {
 	defer config.ResetConfigForTest()
 	workspace := loadDevWorkspaceFromFile(t)
 	fakeDynamic := fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}, &workspace)
-	err := StopDevWorkspace(fakeDynamic)
+	err := StopDevWorkspace(context.Background(), fakeDynamic)
 	assert.NoError(t, err, "Should not return error when stopping workspace")
 	result, err := fakeDynamic.Resource(devworkspaceGVR).Namespace(workspace.GetNamespace()).Get(context.TODO(), workspace.GetName(), metav1.GetOptions{})
 	assert.NoError(t, err, "Unexpected error getting devworkspace")
@@ -219,6 +91,15 @@ func TestGetCurrentWorkspacePod(t *testing.T) {
 			name:         "Multiple pods in namespace",
 			podFilenames: []string{"pod.yaml", "alternate-pod.yaml"},
 		},
+		{
+			name:         "Workspace pod not running",
+			podFilenames: []string{"pending-pod.yaml"},
+			errRegexp:    "workspace pod is not running",
+		},
+		{
+			name:         "Ignores pods that are not running",
+			podFilenames: []string{"pending-pod.yaml", "pod.yaml"},
+		},
 		{
 			name:         "Multiple pods in namespace but no terminal",
 			podFilenames: []string{"alternate-pod.yaml", "alternate-pod-2.yaml"},
@@ -235,7 +116,7 @@ func TestGetCurrentWorkspacePod(t *testing.T) {
 				pods = append(pods, loadPodFromFile(t, filename))
 			}
 			client := fake.NewSimpleClientset(pods...)
-			actualPod, err := GetCurrentWorkspacePod(client)
+			actualPod, err := GetCurrentWorkspacePod(context.Background(), client)
 			if tt.errRegexp != "" {
 				assert.Error(t, err)
 				assert.Regexp(t, tt.errRegexp, err.Error())
@@ -247,6 +128,195 @@ func TestGetCurrentWorkspacePod(t *testing.T) {
 	}
 }
 
+func TestExecCommandInPodIsCancelled(t *testing.T) {
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+	oldSPDYExecutor := NewSPDYExecutor
+	NewSPDYExecutor = func(*rest.Config, string, *url.URL) (remotecommand.Executor, error) {
+		return blockingExecutor{}, nil
+	}
+	defer func() { NewSPDYExecutor = oldSPDYExecutor }()
+
+	// Requests are never sent, as the executor is replaced
+	restconfig := &rest.Config{Host: "https://127.0.0.1:6443"}
+	client, err := kubernetes.NewForConfig(restconfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = ExecCommandInPod(ctx, client, restconfig, "test-pod", "test-container", "sleep infinity")
+	assert.Error(t, err, "Should return error when context is done")
+	assert.Regexp(t, "context deadline exceeded", err.Error())
+}
+
+func TestWriteFileInPod(t *testing.T) {
+	setConfigForTest()
+	defer config.ResetConfigForTest()
+	executor := &recordingExecutor{}
+	oldSPDYExecutor := NewSPDYExecutor
+	NewSPDYExecutor = func(_ *rest.Config, _ string, url *url.URL) (remotecommand.Executor, error) {
+		executor.url = url
+		return executor, nil
+	}
+	defer func() { NewSPDYExecutor = oldSPDYExecutor }()
+
+	restconfig := &rest.Config{Host: "https://127.0.0.1:6443"}
+	client, err := kubernetes.NewForConfig(restconfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	content := "token: $(rm -rf /)`id`\nEOF\n"
+	err = WriteFileInPod(context.Background(), client, restconfig, "test-pod", "test-container", "/home/user/.kube/config", []byte(content))
+	assert.NoError(t, err)
+	assert.Equal(t, content, executor.stdin, "Should stream content over stdin")
+	assert.Equal(t, []string{"/bin/sh", "-c", writeFileScript, "sh", "/home/user/.kube/config"}, executor.url.Query()["command"])
+	assert.NotContains(t, executor.url.String(), "token", "Should not include content in command")
+}
+
+func TestWriteFileScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subdir", "config")
+	content := "token: $(rm -rf /)`id`\nEOF\n"
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command("/bin/sh", "-c", writeFileScript, "sh", path)
+		cmd.Stdin = strings.NewReader(content)
+		output, err := cmd.CombinedOutput()
+		if !assert.NoError(t, err, "Script failed: %s", output) {
+			return
+		}
+		written, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(written), "Should write content verbatim")
+		info, err := os.Stat(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+		entries, err := os.ReadDir(filepath.Dir(path))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1, "Should not leave temporary files")
+	}
+}
+
+func TestListUserNamespaces(t *testing.T) {
+	newProjectClient := func(listErr error, names ...string) *fakedynamic.FakeDynamicClient {
+		var objs []runtime.Object
+		for _, name := range names {
+			project := &unstructured.Unstructured{}
+			project.SetGroupVersionKind(projectGVR.GroupVersion().WithKind("Project"))
+			project.SetName(name)
+			objs = append(objs, project)
+		}
+		client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			projectGVR: "ProjectList",
+		}, objs...)
+		if listErr != nil {
+			client.PrependReactor("list", "projects", func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, listErr
+			})
+		}
+		return client
+	}
+	namespaceClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}},
+	)
+	notFound := k8serrors.NewNotFound(projectGVR.GroupResource(), "")
+	forbidden := k8serrors.NewForbidden(projectGVR.GroupResource(), "", nil)
+
+	namespaces, err := ListUserNamespaces(context.Background(), newProjectClient(nil, "project-b", "project-a"), namespaceClient)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"project-a", "project-b"}, namespaces, "Should list projects")
+
+	namespaces, err = ListUserNamespaces(context.Background(), newProjectClient(notFound), namespaceClient)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ns-a", "ns-b"}, namespaces, "Should list namespaces if Project API is not available")
+
+	pagedNamespaceClient, pages := fake.NewSimpleClientset(), 0
+	pagedNamespaceClient.PrependReactor("list", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		// Serve namespaces in two pages, out of order, to check that all pages are read before sorting
+		pages++
+		if pages == 1 {
+			return true, &corev1.NamespaceList{
+				ListMeta: metav1.ListMeta{Continue: "page-2"},
+				Items:    []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-z"}}},
+			}, nil
+		}
+		return true, &corev1.NamespaceList{Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}}}, nil
+	})
+	namespaces, err = ListUserNamespaces(context.Background(), newProjectClient(notFound), pagedNamespaceClient)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ns-a", "ns-z"}, namespaces, "Should list all pages of namespaces")
+	assert.Equal(t, 2, pages)
+
+	_, err = ListUserNamespaces(context.Background(), newProjectClient(forbidden), namespaceClient)
+	if assert.Error(t, err) {
+		assert.Regexp(t, "failed to list projects", err.Error())
+	}
+
+	forbiddenNamespaceClient := fake.NewSimpleClientset()
+	forbiddenNamespaceClient.PrependReactor("list", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, forbidden
+	})
+	_, err = ListUserNamespaces(context.Background(), newProjectClient(notFound), forbiddenNamespaceClient)
+	if assert.Error(t, err) {
+		assert.Regexp(t, "failed to list namespaces", err.Error())
+	}
+}
+
+func TestClientProviderChecksContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	provider := DefaultClientProvider()
+	_, _, err := provider.NewClientWithToken(ctx, "test-token")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = provider.NewOpenShiftUserClient(ctx, "test-token")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = provider.NewDevWorkspaceClient(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// recordingExecutor is a remotecommand.Executor that records the URL it was created for and its stdin
+type recordingExecutor struct {
+	url   *url.URL
+	stdin string
+}
+
+func (e *recordingExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), options)
+}
+
+func (e *recordingExecutor) StreamWithContext(_ context.Context, options remotecommand.StreamOptions) error {
+	stdin, err := io.ReadAll(options.Stdin)
+	e.stdin = string(stdin)
+	return err
+}
+
+// blockingExecutor is a remotecommand.Executor that blocks until its context is done
+type blockingExecutor struct{}
+
+func (blockingExecutor) Stream(remotecommand.StreamOptions) error {
+	select {}
+}
+
+func (blockingExecutor) StreamWithContext(ctx context.Context, _ remotecommand.StreamOptions) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestTerminalSizeQueue(t *testing.T) {
+	resize := make(chan remotecommand.TerminalSize, 3)
+	resize <- remotecommand.TerminalSize{Width: 80, Height: 24}
+	resize <- remotecommand.TerminalSize{Width: 0, Height: 0}
+	resize <- remotecommand.TerminalSize{Width: 120, Height: 40}
+	close(resize)
+
+	queue := terminalSizeQueue(resize)
+	assert.Equal(t, &remotecommand.TerminalSize{Width: 80, Height: 24}, queue.Next())
+	assert.Equal(t, &remotecommand.TerminalSize{Width: 120, Height: 40}, queue.Next(), "Should skip zero-sized resize events")
+	assert.Nil(t, queue.Next(), "Should return nil when resize channel is closed")
+}
+
 func workspaceIsStarted(t *testing.T, workspace *unstructured.Unstructured) bool {
 	return readUnstructuredPath(t, workspace, reflect.TypeOf(false), "spec", "started").(bool)
 }
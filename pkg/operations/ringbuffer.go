@@ -0,0 +1,81 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package operations
+
+import (
+	"io"
+	"sync"
+)
+
+// RingBuffer is a fixed-size io.Writer that retains the most recently written bytes, discarding
+// older data once full. It is intended to capture the output of exec streams so that it can be
+// replayed later. RingBuffer is safe for concurrent use.
+type RingBuffer struct {
+	mutex sync.Mutex
+	data  []byte
+	start int
+	size  int
+}
+
+var _ io.Writer = (*RingBuffer)(nil)
+
+// NewRingBuffer returns a RingBuffer that retains up to capacity bytes. A RingBuffer with zero
+// capacity discards all writes.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &RingBuffer{
+		data: make([]byte, capacity),
+	}
+}
+
+func (b *RingBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	capacity := len(b.data)
+	if capacity == 0 {
+		return len(p), nil
+	}
+	written := len(p)
+	if len(p) >= capacity {
+		// Only the tail of p fits in the buffer
+		copy(b.data, p[len(p)-capacity:])
+		b.start, b.size = 0, capacity
+		return written, nil
+	}
+	end := (b.start + b.size) % capacity
+	n := copy(b.data[end:], p)
+	copy(b.data, p[n:])
+	b.size += len(p)
+	if b.size > capacity {
+		b.start = (b.start + b.size - capacity) % capacity
+		b.size = capacity
+	}
+	return written, nil
+}
+
+// Bytes returns a copy of the buffered data, oldest first.
+func (b *RingBuffer) Bytes() []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	result := make([]byte, b.size)
+	n := copy(result, b.data[b.start:min(b.start+b.size, len(b.data))])
+	copy(result[n:], b.data[:b.size-n])
+	return result
+}
+
+// Len returns the number of bytes currently buffered.
+func (b *RingBuffer) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.size
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package operations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "Retains writes smaller than capacity",
+			capacity: 10,
+			writes:   []string{"abc", "def"},
+			expected: "abcdef",
+		},
+		{
+			name:     "Fills buffer exactly",
+			capacity: 6,
+			writes:   []string{"abc", "def"},
+			expected: "abcdef",
+		},
+		{
+			name:     "Discards oldest data when full",
+			capacity: 5,
+			writes:   []string{"abc", "def", "gh"},
+			expected: "defgh",
+		},
+		{
+			name:     "Wraps around multiple times",
+			capacity: 4,
+			writes:   []string{"ab", "cd", "ef", "g", "hij"},
+			expected: "ghij",
+		},
+		{
+			name:     "Keeps tail of writes larger than capacity",
+			capacity: 4,
+			writes:   []string{"ab", "cdefghij"},
+			expected: "ghij",
+		},
+		{
+			name:     "Discards all writes with zero capacity",
+			capacity: 0,
+			writes:   []string{"abc"},
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := NewRingBuffer(tt.capacity)
+			for _, write := range tt.writes {
+				n, err := buffer.Write([]byte(write))
+				assert.NoError(t, err)
+				assert.Equal(t, len(write), n, "Write should report all bytes as written")
+			}
+			assert.Equal(t, tt.expected, string(buffer.Bytes()))
+			assert.Equal(t, len(tt.expected), buffer.Len())
+		})
+	}
+}
@@ -11,8 +11,12 @@
 package test
 
 import (
+	"context"
+
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -27,6 +31,12 @@ import (
 	k8stesting "k8s.io/client-go/testing"
 )
 
+var projectGVR = schema.GroupVersionResource{
+	Group:    "project.openshift.io",
+	Version:  "v1",
+	Resource: "projects",
+}
+
 var userGVK = schema.GroupVersionKind{
 	Group:   "user.openshift.io",
 	Version: "v1",
@@ -39,34 +49,41 @@ type NoOpClientProvider struct{}
 
 var _ operations.ClientProvider = (*NoOpClientProvider)(nil)
 
-func (NoOpClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error) {
+func (NoOpClientProvider) NewDevWorkspaceClient(context.Context) (dynamic.Interface, *rest.Config, error) {
+	return nil, nil, nil
+}
+
+func (NoOpClientProvider) NewClientWithToken(_ context.Context, token string) (kubernetes.Interface, *rest.Config, error) {
 	return nil, nil, nil
 }
 
-func (NoOpClientProvider) NewClientWithToken(token string) (kubernetes.Interface, *rest.Config, error) {
+func (NoOpClientProvider) NewOpenShiftUserClient(_ context.Context, token string) (dynamic.Interface, *rest.Config, error) {
 	return nil, nil, nil
 }
 
-func (NoOpClientProvider) NewOpenShiftUserClient(token string) (dynamic.Interface, *rest.Config, error) {
+func (NoOpClientProvider) NewServiceAccountClient(context.Context) (kubernetes.Interface, *rest.Config, error) {
 	return nil, nil, nil
 }
 
 // FakeClientProvider returns fake clientsets and dynamic clients that are initialized with
-// objects. SelfSubjectReview responses use the request token as the returned UID.
+// objects. SelfSubjectReview and TokenReview responses use the request token as the returned UID.
 type FakeClientProvider struct {
 	InitialObjs    []runtime.Object
 	InitialDynamic []runtime.Object
 	UserToken      string
+	// Projects are the OpenShift projects served to clients returned by NewOpenShiftUserClient. If nil, the
+	// OpenShift Project API is not available
+	Projects []string
 }
 
 var _ operations.ClientProvider = (*FakeClientProvider)(nil)
 
-func (p FakeClientProvider) NewDevWorkspaceClient() (dynamic.Interface, *rest.Config, error) {
+func (p FakeClientProvider) NewDevWorkspaceClient(context.Context) (dynamic.Interface, *rest.Config, error) {
 	client := fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}, p.InitialDynamic...)
 	return client, &rest.Config{}, nil
 }
 
-func (p FakeClientProvider) NewClientWithToken(token string) (kubernetes.Interface, *rest.Config, error) {
+func (p FakeClientProvider) NewClientWithToken(_ context.Context, token string) (kubernetes.Interface, *rest.Config, error) {
 	client := fake.NewSimpleClientset(p.InitialObjs...)
 	client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
 		return true, &authenticationv1.SelfSubjectReview{
@@ -80,7 +97,7 @@ func (p FakeClientProvider) NewClientWithToken(token string) (kubernetes.Interfa
 	return &WrapFakeClientCoreV1{client}, &rest.Config{}, nil
 }
 
-func (p FakeClientProvider) NewOpenShiftUserClient(token string) (dynamic.Interface, *rest.Config, error) {
+func (p FakeClientProvider) NewOpenShiftUserClient(_ context.Context, token string) (dynamic.Interface, *rest.Config, error) {
 	// Fake OpenShift User API -- Use '~' as name since API endpoint apis/user.openshift.io/v1/users/~ serves
 	// current user. Use token as authorized user ID for convenience
 	fakeUser := &unstructured.Unstructured{
@@ -92,10 +109,40 @@ func (p FakeClientProvider) NewOpenShiftUserClient(token string) (dynamic.Interf
 		},
 	}
 	fakeUser.SetGroupVersionKind(userGVK)
-	client := fakedynamic.NewSimpleDynamicClient(&runtime.Scheme{}, fakeUser)
+	objs := []runtime.Object{fakeUser}
+	for _, name := range p.Projects {
+		project := &unstructured.Unstructured{}
+		project.SetGroupVersionKind(projectGVR.GroupVersion().WithKind("Project"))
+		project.SetName(name)
+		objs = append(objs, project)
+	}
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		projectGVR: "ProjectList",
+	}, objs...)
+	if p.Projects == nil {
+		client.PrependReactor("list", "projects", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, nil, apierrors.NewNotFound(projectGVR.GroupResource(), "")
+		})
+	}
 	return client, &rest.Config{}, nil
 }
 
+func (p FakeClientProvider) NewServiceAccountClient(context.Context) (kubernetes.Interface, *rest.Config, error) {
+	client := fake.NewSimpleClientset(p.InitialObjs...)
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		return true, &authenticationv1.TokenReview{
+			Status: authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User: authenticationv1.UserInfo{
+					UID: review.Spec.Token,
+				},
+			},
+		}, nil
+	})
+	return &WrapFakeClientCoreV1{client}, &rest.Config{}, nil
+}
+
 // Functions below are to wrap the RESTClient in fake.Clientset (which is by default nil)
 // This is required to allow allow resolving requests for pods/exec in tests.
 type WrapFakeClientCoreV1 struct {
@@ -112,7 +159,8 @@ type wrapFakeClientHTTPClient struct {
 
 func (f *wrapFakeClientHTTPClient) RESTClient() restclient.Interface {
 	// return &fakeKubeRESTClient{f.CoreV1Interface.RESTClient()}
-	return &fakehttp.RESTClient{}
+	// Set GroupVersion to allow encoding pods/exec options as query parameters in request URLs
+	return &fakehttp.RESTClient{GroupVersion: corev1.SchemeGroupVersion}
 }
 
 type fakeKubeRESTClient struct {
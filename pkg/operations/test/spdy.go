@@ -16,6 +16,7 @@ import (
 	"io"
 	"net/url"
 	"strings"
+	"sync"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
@@ -79,3 +80,57 @@ func (f *FakeSPDYExecutor) stream(options remotecommand.StreamOptions) error {
 
 	return nil
 }
+
+// EchoSPDYExecutorProvider provides a function that allows replacing remotecommand.NewSPDYExecutor
+// with an executor that emulates an interactive terminal session.
+type EchoSPDYExecutorProvider struct {
+	EchoSPDYExecutor
+}
+
+func (f *EchoSPDYExecutorProvider) NewEchoSPDYExecutor(_ *rest.Config, _ string, _ *url.URL) (remotecommand.Executor, error) {
+	return &f.EchoSPDYExecutor, nil
+}
+
+// EchoSPDYExecutor is a fake SPDYExecutor that writes everything read from stdin back to stdout
+// until stdin is closed or the context is cancelled. Terminal sizes read from the TerminalSizeQueue
+// are saved in order to allow verifying resize events.
+type EchoSPDYExecutor struct {
+	Resizes []remotecommand.TerminalSize
+	mutex   sync.Mutex
+}
+
+var _ remotecommand.Executor = (*EchoSPDYExecutor)(nil)
+
+func (f *EchoSPDYExecutor) Stream(options remotecommand.StreamOptions) error {
+	return f.StreamWithContext(context.Background(), options)
+}
+
+func (f *EchoSPDYExecutor) StreamWithContext(ctx context.Context, options remotecommand.StreamOptions) error {
+	if options.TerminalSizeQueue != nil {
+		go func() {
+			for size := options.TerminalSizeQueue.Next(); size != nil; size = options.TerminalSizeQueue.Next() {
+				f.mutex.Lock()
+				f.Resizes = append(f.Resizes, *size)
+				f.mutex.Unlock()
+			}
+		}()
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(options.Stdout, options.Stdin)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetResizes returns the terminal sizes received so far.
+func (f *EchoSPDYExecutor) GetResizes() []remotecommand.TerminalSize {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]remotecommand.TerminalSize{}, f.Resizes...)
+}
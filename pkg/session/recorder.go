@@ -0,0 +1,155 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	asciicastVersion       = 2
+	asciicastOutputEvent   = "o"
+	asciicastResizeEvent   = "r"
+	defaultRecordingWidth  = 80
+	defaultRecordingHeight = 24
+)
+
+// asciicastHeader is the first line of an asciicast v2 recording.
+// See https://docs.asciinema.org/manual/asciicast/v2/
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes terminal output and resize events to a file in asciicast v2 format.
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	mutex     sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	startTime time.Time
+	// Trailing bytes of an incomplete UTF-8 sequence from the previous write, as asciicast
+	// events must contain valid UTF-8 strings.
+	pending []byte
+}
+
+// NewRecorder creates a recording at path for a session running shell. The file must not exist.
+func NewRecorder(path, shell string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+	recorder := &Recorder{
+		file:      file,
+		writer:    bufio.NewWriter(file),
+		startTime: time.Now(),
+	}
+	header, err := json.Marshal(asciicastHeader{
+		Version:   asciicastVersion,
+		Width:     defaultRecordingWidth,
+		Height:    defaultRecordingHeight,
+		Timestamp: recorder.startTime.Unix(),
+		Env:       map[string]string{"SHELL": shell},
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := recorder.writeLine(header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+	return recorder, nil
+}
+
+// Output records terminal output.
+func (r *Recorder) Output(p []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	data := append(r.pending, p...)
+	complete := completeUTF8Prefix(data)
+	r.pending = append([]byte{}, data[complete:]...)
+	if complete == 0 {
+		return nil
+	}
+	return r.writeEvent(asciicastOutputEvent, string(data[:complete]))
+}
+
+// Resize records a change in terminal size.
+func (r *Recorder) Resize(size remotecommand.TerminalSize) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.writeEvent(asciicastResizeEvent, fmt.Sprintf("%dx%d", size.Width, size.Height))
+}
+
+// Close flushes the recording and closes the underlying file.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.pending) > 0 {
+		if err := r.writeEvent(asciicastOutputEvent, string(r.pending)); err != nil {
+			r.file.Close()
+			return err
+		}
+		r.pending = nil
+	}
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+func (r *Recorder) writeEvent(eventType, data string) error {
+	elapsed := time.Since(r.startTime).Seconds()
+	event, err := json.Marshal([]interface{}{elapsed, eventType, data})
+	if err != nil {
+		return err
+	}
+	if err := r.writeLine(event); err != nil {
+		return err
+	}
+	// Flush each event so that recordings are complete even if the server is terminated abruptly
+	return r.writer.Flush()
+}
+
+func (r *Recorder) writeLine(line []byte) error {
+	if _, err := r.writer.Write(line); err != nil {
+		return err
+	}
+	return r.writer.WriteByte('\n')
+}
+
+// completeUTF8Prefix returns the length of the longest prefix of data that does not end in an
+// incomplete UTF-8 sequence.
+func completeUTF8Prefix(data []byte) int {
+	// A UTF-8 encoded rune is at most utf8.UTFMax bytes, so only the tail needs to be checked
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
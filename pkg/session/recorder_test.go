@@ -0,0 +1,87 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.cast")
+	recorder, err := NewRecorder(path, "/bin/bash")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, recorder.Output([]byte("hello\n")))
+	assert.NoError(t, recorder.Resize(remotecommand.TerminalSize{Width: 120, Height: 40}))
+	// "é" is split across writes; it should be recorded as a single event once complete
+	assert.NoError(t, recorder.Output([]byte{'c', 'a', 'f', 0xc3}))
+	assert.NoError(t, recorder.Output([]byte{0xa9, '\n'}))
+	// Incomplete sequences are flushed on close, with each invalid byte replaced
+	assert.NoError(t, recorder.Output([]byte{0xe2, 0x82}))
+	assert.NoError(t, recorder.Close())
+
+	_, err = NewRecorder(path, "/bin/bash")
+	assert.Error(t, err, "Should not overwrite existing recording")
+
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	assert.True(t, scanner.Scan())
+	header := asciicastHeader{}
+	assert.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, uint16(80), header.Width)
+	assert.Equal(t, uint16(24), header.Height)
+	assert.Equal(t, "/bin/bash", header.Env["SHELL"])
+
+	var events [][]interface{}
+	for scanner.Scan() {
+		event := []interface{}{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		if assert.Len(t, event, 3) {
+			assert.IsType(t, float64(0), event[0], "Event time should be a number")
+			events = append(events, event[1:])
+		}
+	}
+	assert.Equal(t, [][]interface{}{
+		{"o", "hello\n"},
+		{"r", "120x40"},
+		{"o", "caf"},
+		{"o", "é\n"},
+		{"o", "\uFFFD\uFFFD"},
+	}, events)
+}
+
+func TestRecordingPath(t *testing.T) {
+	registry := NewRegistry(0, "")
+	_, err := registry.RecordingPath("0123456789abcdef0123456789abcdef")
+	assert.Error(t, err, "Should fail when recording is disabled")
+
+	registry = NewRegistry(0, "/recordings")
+	path, err := registry.RecordingPath("0123456789abcdef0123456789abcdef")
+	assert.NoError(t, err)
+	assert.Equal(t, "/recordings/0123456789abcdef0123456789abcdef.cast", path)
+	for _, id := range []string{"", "../etc/passwd", "0123456789ABCDEF0123456789ABCDEF", "0123456789abcdef0123456789abcdef/.."} {
+		_, err := registry.RecordingPath(id)
+		assert.Error(t, err, "Should reject invalid session ID '%s'", id)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const recordingFileExtension = ".cast"
+
+var sessionIDRegexp = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Registry tracks terminal sessions that are currently running.
+type Registry struct {
+	mutex           sync.Mutex
+	sessions        map[string]*Session
+	scrollbackBytes int
+	recordingDir    string
+	closed          bool
+}
+
+// NewRegistry returns a Registry for sessions that retain up to scrollbackBytes of output for replay.
+// If recordingDir is not empty, sessions are recorded in asciicast v2 format to files in that directory.
+func NewRegistry(scrollbackBytes int, recordingDir string) *Registry {
+	return &Registry{
+		sessions:        map[string]*Session{},
+		scrollbackBytes: scrollbackBytes,
+		recordingDir:    recordingDir,
+	}
+}
+
+// Start starts a new session running shell in the specified container on behalf of the user with UID ownerUID.
+// The session is removed from the registry once the shell exits. The session outlives ctx, but keeps its values
+// (e.g. the request's logger).
+func (r *Registry) Start(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName, shell, ownerUID string) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	log := logging.FromContext(ctx).WithField("session", id)
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stdin, stdinInput := io.Pipe()
+	now := time.Now()
+	session := &Session{
+		ID:            id,
+		PodName:       podName,
+		ContainerName: containerName,
+		Shell:         shell,
+		StartTime:     now,
+		OwnerUID:      ownerUID,
+		lastActivity:  now,
+		scrollback:    operations.NewRingBuffer(r.scrollbackBytes),
+		stdin:         stdinInput,
+		resize:        make(chan remotecommand.TerminalSize, 1),
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+	if r.recordingDir != "" {
+		recorder, err := NewRecorder(filepath.Join(r.recordingDir, id+recordingFileExtension), shell)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		session.recorder = recorder
+	}
+
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		cancel()
+		if session.recorder != nil {
+			if err := session.recorder.Close(); err != nil {
+				log.Errorf("Failed to close recording for session %s: %s", id, err)
+			}
+		}
+		return nil, fmt.Errorf("failed to start session: server is shutting down")
+	}
+	r.sessions[id] = session
+	r.mutex.Unlock()
+
+	go func() {
+		defer cancel()
+		err := operations.ExecInteractiveInPod(ctx, client, restconfig, podName, containerName, []string{shell}, stdin, session, session.resize)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Session %s in container %s ended with error: %s", id, containerName, err)
+		} else {
+			log.Infof("Session %s in container %s ended", id, containerName)
+		}
+		stdin.Close()
+		r.remove(id)
+		session.end()
+	}()
+	log.Infof("Started session %s running %s in container %s", id, shell, containerName)
+	return session, nil
+}
+
+// Get returns the session with the given ID, if it exists.
+func (r *Registry) Get(id string) (*Session, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	session, ok := r.sessions[id]
+	return session, ok
+}
+
+// List returns all running sessions, ordered by start time.
+func (r *Registry) List() []*Session {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, session := range r.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+	return sessions
+}
+
+// Terminate terminates the session with the given ID, returning false if it does not exist.
+func (r *Registry) Terminate(id string) bool {
+	session, ok := r.Get(id)
+	if !ok {
+		return false
+	}
+	session.Terminate()
+	return true
+}
+
+// Shutdown terminates all running sessions and waits for them to end, or until ctx is done. Sessions
+// cannot be started once Shutdown is called.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mutex.Lock()
+	r.closed = true
+	r.mutex.Unlock()
+
+	sessions := r.List()
+	for _, session := range sessions {
+		session.Terminate()
+	}
+	for _, session := range sessions {
+		select {
+		case <-session.Done():
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %d sessions to end: %w", len(r.List()), ctx.Err())
+		}
+	}
+	return nil
+}
+
+// RecordingPath returns the path to the recording for the session with the given ID. Recordings remain
+// available after sessions end. Returns an error if recording is disabled or the ID is invalid; the
+// returned path may not exist.
+func (r *Registry) RecordingPath(id string) (string, error) {
+	if r.recordingDir == "" {
+		return "", fmt.Errorf("session recording is not enabled")
+	}
+	if !sessionIDRegexp.MatchString(id) {
+		return "", fmt.Errorf("invalid session ID '%s'", id)
+	}
+	return filepath.Join(r.recordingDir, id+recordingFileExtension), nil
+}
+
+func (r *Registry) remove(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.sessions, id)
+}
+
+func newSessionID() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(idBytes), nil
+}
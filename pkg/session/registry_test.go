@@ -0,0 +1,170 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package session
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTerminal struct {
+	mutex    sync.Mutex
+	output   bytes.Buffer
+	detached bool
+}
+
+func (t *testTerminal) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.output.Write(p)
+}
+
+func (t *testTerminal) Detach() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.detached = true
+}
+
+func (t *testTerminal) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.output.String()
+}
+
+func (t *testTerminal) isDetached() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.detached
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	executor := test.EchoSPDYExecutorProvider{}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = executor.NewEchoSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	client, restconfig, err := test.FakeClientProvider{}.NewClientWithToken(context.Background(), "test-token")
+	assert.NoError(t, err)
+
+	registry := NewRegistry(1024, "")
+	session, err := registry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	if !assert.NoError(t, err) {
+		return
+	}
+	found, ok := registry.Get(session.ID)
+	assert.True(t, ok, "Session should be registered")
+	assert.Equal(t, session, found)
+	assert.Equal(t, "test-uid", session.OwnerUID)
+
+	terminal := &testTerminal{}
+	assert.NoError(t, session.Attach(terminal))
+	assert.True(t, session.Info().Attached)
+	assert.NoError(t, session.Input([]byte("first\n")))
+	assert.Eventually(t, func() bool { return terminal.String() == "first\n" }, time.Second, 10*time.Millisecond)
+
+	// Output produced while detached is replayed on attach
+	session.Detach(terminal)
+	assert.False(t, session.Info().Attached)
+	assert.NoError(t, session.Input([]byte("second\n")))
+	assert.Eventually(t, func() bool {
+		return string(session.scrollback.Bytes()) == "first\nsecond\n"
+	}, time.Second, 10*time.Millisecond)
+
+	reattached := &testTerminal{}
+	assert.NoError(t, session.Attach(reattached))
+	assert.Equal(t, "first\nsecond\n", reattached.String(), "Should replay scrollback on attach")
+	assert.Equal(t, "first\n", terminal.String(), "Detached terminal should not receive output")
+
+	// Attaching another terminal displaces the current one
+	displacing := &testTerminal{}
+	assert.NoError(t, session.Attach(displacing))
+	assert.True(t, reattached.isDetached(), "Previous terminal should be detached")
+
+	assert.True(t, registry.Terminate(session.ID))
+	select {
+	case <-session.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Session should end when terminated")
+	}
+	_, ok = registry.Get(session.ID)
+	assert.False(t, ok, "Session should be removed once ended")
+	assert.Error(t, session.Attach(&testTerminal{}), "Should not attach to ended session")
+	assert.False(t, registry.Terminate(session.ID))
+}
+
+func TestScrollbackIsBounded(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	executor := test.EchoSPDYExecutorProvider{}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = executor.NewEchoSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	client, restconfig, err := test.FakeClientProvider{}.NewClientWithToken(context.Background(), "test-token")
+	assert.NoError(t, err)
+
+	registry := NewRegistry(8, "")
+	session, err := registry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer session.Terminate()
+	assert.NoError(t, session.Input([]byte("0123456789")))
+	assert.NoError(t, session.Input([]byte("abcdef")))
+	assert.Eventually(t, func() bool {
+		return session.scrollback.Len() == 8
+	}, time.Second, 10*time.Millisecond)
+
+	terminal := &testTerminal{}
+	assert.NoError(t, session.Attach(terminal))
+	assert.Equal(t, "89abcdef", terminal.String(), "Should only replay most recent output")
+}
+
+func TestShutdownTerminatesSessions(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	executor := test.EchoSPDYExecutorProvider{}
+	oldSPDYExecutor := operations.NewSPDYExecutor
+	operations.NewSPDYExecutor = executor.NewEchoSPDYExecutor
+	defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
+
+	client, restconfig, err := test.FakeClientProvider{}.NewClientWithToken(context.Background(), "test-token")
+	assert.NoError(t, err)
+
+	registry := NewRegistry(1024, "")
+	first, err := registry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	assert.NoError(t, err)
+	second, err := registry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, registry.Shutdown(ctx))
+	for _, session := range []*Session{first, second} {
+		select {
+		case <-session.Done():
+		default:
+			t.Errorf("Session %s should have ended on shutdown", session.ID)
+		}
+	}
+	assert.Empty(t, registry.List())
+
+	_, err = registry.Start(context.Background(), client, restconfig, "test-pod", "test-container", "/bin/bash", "test-uid")
+	assert.Error(t, err, "Should not start sessions after shutdown")
+}
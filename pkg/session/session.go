@@ -0,0 +1,208 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/api"
+	"github.com/redhat-developer/web-terminal-exec/pkg/constants"
+	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Terminal is a client attached to a session, e.g. a WebSocket connection. Session output is
+// written to the attached terminal.
+type Terminal interface {
+	io.Writer
+	// Detach is called when the terminal is displaced by another terminal attaching to the same session.
+	Detach()
+}
+
+// Session is a terminal session running in a workspace container. Sessions keep running when their
+// terminal disconnects, allowing clients to reattach and receive output produced in the meantime.
+type Session struct {
+	ID            string
+	PodName       string
+	ContainerName string
+	Shell         string
+	StartTime     time.Time
+	// OwnerUID is the UID of the user that started the session. Only the owner may attach to the session
+	OwnerUID string
+
+	mutex        sync.Mutex
+	lastActivity time.Time
+	scrollback   *operations.RingBuffer
+	recorder     *Recorder
+	terminal     Terminal
+	detachTimer  *time.Timer
+
+	stdin  *io.PipeWriter
+	resize chan remotecommand.TerminalSize
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Info returns a description of the session for use in API responses.
+func (s *Session) Info() api.SessionInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return api.SessionInfo{
+		ID:            s.ID,
+		PodName:       s.PodName,
+		ContainerName: s.ContainerName,
+		Shell:         s.Shell,
+		StartTime:     s.StartTime,
+		LastActivity:  s.lastActivity,
+		Attached:      s.terminal != nil,
+		Recorded:      s.recorder != nil,
+	}
+}
+
+// Attach replays the session's scrollback to terminal and starts forwarding output to it. If another
+// terminal is attached to the session, it is detached.
+func (s *Session) Attach(terminal Terminal) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isDone() {
+		return fmt.Errorf("session %s has ended", s.ID)
+	}
+	if s.scrollback.Len() > 0 {
+		if _, err := terminal.Write(s.scrollback.Bytes()); err != nil {
+			return fmt.Errorf("failed to replay scrollback: %w", err)
+		}
+	}
+	if s.terminal != nil {
+		s.terminal.Detach()
+	}
+	if s.detachTimer != nil {
+		s.detachTimer.Stop()
+		s.detachTimer = nil
+	}
+	s.terminal = terminal
+	return nil
+}
+
+// Detach stops forwarding output to terminal if it is currently attached to the session. Sessions
+// that are not reattached within constants.DetachedSessionTimeout are terminated.
+func (s *Session) Detach(terminal Terminal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.terminal != terminal {
+		return
+	}
+	s.terminal = nil
+	s.detachTimer = time.AfterFunc(constants.DetachedSessionTimeout, func() {
+		logrus.Infof("Terminating session %s: not reattached within %s", s.ID, constants.DetachedSessionTimeout)
+		s.Terminate()
+	})
+}
+
+// Input writes data to the session's stdin.
+func (s *Session) Input(data []byte) error {
+	s.touch()
+	_, err := s.stdin.Write(data)
+	return err
+}
+
+// Resize queues a terminal resize for the session. Only the latest size is kept if the previous
+// resize has not been applied yet.
+func (s *Session) Resize(size remotecommand.TerminalSize) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isDone() {
+		return
+	}
+	if s.recorder != nil && size.Width > 0 && size.Height > 0 {
+		if err := s.recorder.Resize(size); err != nil {
+			logrus.Errorf("Failed to record resize for session %s: %s", s.ID, err)
+		}
+	}
+	for {
+		select {
+		case s.resize <- size:
+			return
+		default:
+			select {
+			case <-s.resize:
+			default:
+			}
+		}
+	}
+}
+
+// Terminate stops the session's process. Done is closed once the session has ended.
+func (s *Session) Terminate() {
+	s.cancel()
+}
+
+// Done returns a channel that is closed once the session has ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Write records output from the session's process and forwards it to the attached terminal, if any.
+func (s *Session) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastActivity = time.Now()
+	if _, err := s.scrollback.Write(p); err != nil {
+		logrus.Debugf("Failed to write output of session %s to scrollback: %s", s.ID, err)
+	}
+	if s.recorder != nil {
+		if err := s.recorder.Output(p); err != nil {
+			logrus.Errorf("Failed to record output for session %s: %s", s.ID, err)
+		}
+	}
+	if s.terminal != nil {
+		if _, err := s.terminal.Write(p); err != nil {
+			logrus.Debugf("Failed to write output of session %s to terminal: %s", s.ID, err)
+		}
+	}
+	return len(p), nil
+}
+
+func (s *Session) touch() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastActivity = time.Now()
+}
+
+// end marks the session as ended once its process has exited. Must only be called once.
+func (s *Session) end() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	close(s.done)
+	close(s.resize)
+	if s.detachTimer != nil {
+		s.detachTimer.Stop()
+	}
+	if s.recorder != nil {
+		if err := s.recorder.Close(); err != nil {
+			logrus.Errorf("Failed to close recording for session %s: %s", s.ID, err)
+		}
+	}
+	s.terminal = nil
+}
+
+func (s *Session) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupIsNoOpWithoutEndpoint(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	t.Setenv(otlpEndpointEnvVar, "")
+	t.Setenv(otlpTracesEndpointEnvVar, "")
+	oldTracerProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(oldTracerProvider)
+
+	shutdown, err := Setup(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	_, isSDK := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.False(t, isSDK, "Should not configure SDK without an endpoint")
+
+	ctx := Extract(context.Background(), http.Header{"Traceparent": []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}})
+	_, span := StartSpan(ctx, "test")
+	defer span.End()
+	assert.False(t, span.IsRecording(), "Spans should not be recorded")
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String(), "Trace context should still be propagated")
+}
+
+func TestSetupIsNoOpWhenDisabled(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	t.Setenv(otlpEndpointEnvVar, "http://localhost:4318")
+	t.Setenv(sdkDisabledEnvVar, "true")
+	oldTracerProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(oldTracerProvider)
+
+	_, err := Setup(context.Background())
+	assert.NoError(t, err)
+	_, isSDK := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.False(t, isSDK, "Should not configure SDK when disabled")
+}
+
+func TestSetupExportsToEndpoint(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+	t.Setenv(otlpEndpointEnvVar, "http://localhost:4318")
+	oldTracerProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(oldTracerProvider)
+
+	shutdown, err := Setup(context.Background())
+	assert.NoError(t, err)
+	assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+	_, span := StartSpan(context.Background(), "test", trace.WithSpanKind(trace.SpanKindServer))
+	assert.True(t, span.IsRecording())
+	span.End()
+	// Don't try to export to the endpoint, which does not exist
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = shutdown(ctx)
+}
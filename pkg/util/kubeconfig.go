@@ -14,12 +14,17 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
 
+// serviceAccountCAFile is the CA bundle used to verify the in-cluster API server
+const serviceAccountCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
 type KubeConfig struct {
 	APIVersion     string     `json:"apiVersion"`
 	Clusters       []Clusters `json:"clusters"`
@@ -35,8 +40,11 @@ type Clusters struct {
 }
 
 type ClusterInfo struct {
-	CertificateAuthority string `json:"certificate-authority"`
-	Server               string `json:"server"`
+	CertificateAuthority     string `json:"certificate-authority,omitempty"`
+	CertificateAuthorityData string `json:"certificate-authority-data,omitempty"`
+	Server                   string `json:"server"`
+	TLSServerName            string `json:"tls-server-name,omitempty"`
+	ProxyURL                 string `json:"proxy-url,omitempty"`
 }
 
 type Users struct {
@@ -44,8 +52,26 @@ type Users struct {
 	User User   `json:"user"`
 }
 
+// User holds the credentials of a user in a kubeconfig: either a bearer token, or an exec credential plugin that
+// returns one.
 type User struct {
-	Token string `json:"token"`
+	Token string      `json:"token,omitempty"`
+	Exec  *ExecConfig `json:"exec,omitempty"`
+}
+
+// ExecConfig configures a client-go exec credential plugin (client.authentication.k8s.io/v1)
+type ExecConfig struct {
+	APIVersion      string       `json:"apiVersion"`
+	Command         string       `json:"command"`
+	Args            []string     `json:"args,omitempty"`
+	Env             []ExecEnvVar `json:"env,omitempty"`
+	InteractiveMode string       `json:"interactiveMode"`
+}
+
+// ExecEnvVar is an environment variable set for an exec credential plugin
+type ExecEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type Contexts struct {
@@ -59,54 +85,270 @@ type Context struct {
 	User      string `json:"user"`
 }
 
-func CreateKubeConfigText(token, namespace, username string) (string, error) {
+// CreateKubeConfigText returns a kubeconfig for the API server of the current cluster in which username has
+// the given credentials. Non-empty fields in overrides replace the defaults for the cluster. The current context
+// uses namespace; a context is also added for each of additionalNamespaces.
+func CreateKubeConfigText(credentials User, overrides config.ClusterOverrides, namespace, username string, additionalNamespaces ...string) (string, error) {
+	cluster, err := clusterInfo(overrides)
+	if err != nil {
+		return "", err
+	}
+	kubeconfig := generateKubeConfig(credentials, cluster, namespace, username, additionalNamespaces...)
+
+	bytes, err := yaml.Marshal(&kubeconfig)
+	if err != nil {
+		logrus.Errorf("Failed to marshal kubeconfig: %s", err)
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// MergeKubeConfig adds the cluster, user and contexts generated for credentials, namespace, username and
+// additionalNamespaces to the existing kubeconfig, replacing entries with the same names, and makes the generated
+// context for namespace current. Other clusters, users and contexts in existing are preserved. If existing is
+// empty, the generated kubeconfig is returned.
+func MergeKubeConfig(existing string, credentials User, overrides config.ClusterOverrides, namespace, username string, additionalNamespaces ...string) (string, error) {
+	cluster, err := clusterInfo(overrides)
+	if err != nil {
+		return "", err
+	}
+	return mergeKubeConfig(existing, generateKubeConfig(credentials, cluster, namespace, username, additionalNamespaces...))
+}
+
+// mergeKubeConfig adds the clusters, users and contexts in generated to the existing kubeconfig, replacing entries
+// with the same names, and makes the current context of generated current.
+func mergeKubeConfig(existing string, generated *KubeConfig) (string, error) {
+	generatedBytes, err := yaml.Marshal(generated)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal kubeconfig: %w", err)
+	}
+	var generatedConfig map[string]interface{}
+	if err := yaml.Unmarshal(generatedBytes, &generatedConfig); err != nil {
+		return "", fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	merged := map[string]interface{}{}
+	if strings.TrimSpace(existing) != "" {
+		if merged, err = parseKubeConfig(existing); err != nil {
+			return "", err
+		}
+	}
+	for _, key := range []string{"clusters", "users", "contexts"} {
+		entries, err := namedEntries(merged, key)
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range generatedConfig[key].([]interface{}) {
+			entries = upsertNamedEntry(entries, entry.(map[string]interface{}))
+		}
+		merged[key] = entries
+	}
+	merged["current-context"] = generated.CurrentContext
+	if merged["apiVersion"] == nil {
+		merged["apiVersion"] = generated.APIVersion
+	}
+	if merged["kind"] == nil {
+		merged["kind"] = generated.Kind
+	}
+	return marshalKubeConfig(merged)
+}
+
+// UpdateKubeConfigToken replaces the credentials of the user named username in kubeconfig with token, leaving
+// clusters, contexts and other users unchanged. The user is added if it is not present in kubeconfig.
+func UpdateKubeConfigToken(kubeconfig, username, token string) (string, error) {
+	config, err := parseKubeConfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	users, err := namedEntries(config, "users")
+	if err != nil {
+		return "", err
+	}
+	config["users"] = upsertNamedEntry(users, map[string]interface{}{
+		"name": username,
+		"user": map[string]interface{}{"token": token},
+	})
+	return marshalKubeConfig(config)
+}
+
+// RemoveKubeConfigCredentials removes the credentials of the user named username from kubeconfig, leaving an
+// empty user so that contexts referring to it remain valid. Clusters, contexts and other users are unchanged.
+func RemoveKubeConfigCredentials(kubeconfig, username string) (string, error) {
+	config, err := parseKubeConfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	users, err := namedEntries(config, "users")
+	if err != nil {
+		return "", err
+	}
+	for _, user := range users {
+		if entry, ok := user.(map[string]interface{}); ok && entry["name"] == username {
+			entry["user"] = map[string]interface{}{}
+		}
+	}
+	return marshalKubeConfig(config)
+}
+
+// clusterInfo returns the cluster for use in a kubeconfig in the workspace: the in-cluster API server, verified
+// using the service account CA bundle, unless overridden by overrides.
+func clusterInfo(overrides config.ClusterOverrides) (ClusterInfo, error) {
+	cluster := ClusterInfo{
+		Server:        overrides.Server,
+		TLSServerName: overrides.TLSServerName,
+		ProxyURL:      overrides.ProxyURL,
+	}
+	if cluster.Server == "" {
+		server, err := kubernetesServer()
+		if err != nil {
+			return ClusterInfo{}, err
+		}
+		cluster.Server = server
+	}
+	if overrides.CertificateAuthorityData != "" {
+		cluster.CertificateAuthorityData = overrides.CertificateAuthorityData
+	} else {
+		cluster.CertificateAuthority = serviceAccountCAFile
+	}
+	return cluster, nil
+}
+
+// kubernetesServer returns the URL of the API server for use in a kubeconfig in the workspace.
+func kubernetesServer() (string, error) {
 	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
 	if host == "" || port == "" {
 		return "", errors.NewInternalError("Could not find $KUBERNETES_SERVICE_HOST or $KUBERNETES_SERVICE_PORT")
 	}
+	return "https://" + net.JoinHostPort(host, port), nil
+}
+
+// parseKubeConfig parses kubeconfig into a generic map, so that fields that are not known to this package are
+// preserved when it is written back.
+func parseKubeConfig(kubeconfig string) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(kubeconfig), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("kubeconfig is empty")
+	}
+	return config, nil
+}
 
-	server := "https://" + net.JoinHostPort(host, port)
-	kubeconfig := generateKubeConfig(token, server, namespace, username)
+// namedEntries returns the list of named entries (clusters, users or contexts) under key in config.
+func namedEntries(config map[string]interface{}, key string) ([]interface{}, error) {
+	if config[key] == nil {
+		return nil, nil
+	}
+	entries, ok := config[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %s is not a list", key)
+	}
+	return entries, nil
+}
 
-	bytes, err := yaml.Marshal(&kubeconfig)
+// upsertNamedEntry replaces the entries in entries with the same name as entry, or appends entry if there are none.
+func upsertNamedEntry(entries []interface{}, entry map[string]interface{}) []interface{} {
+	found := false
+	for idx, existing := range entries {
+		existingEntry, ok := existing.(map[string]interface{})
+		if ok && existingEntry["name"] == entry["name"] {
+			entries[idx] = entry
+			found = true
+		}
+	}
+	if !found {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func marshalKubeConfig(config map[string]interface{}) (string, error) {
+	bytes, err := yaml.Marshal(config)
 	if err != nil {
-		logrus.Errorf("Failed to marshal kubeconfig: %s", err)
-		return "", err
+		return "", fmt.Errorf("failed to marshal kubeconfig: %w", err)
 	}
 	return string(bytes), nil
 }
 
-func generateKubeConfig(token, server, namespace, username string) *KubeConfig {
-	currentContext := fmt.Sprintf("%s-context", username)
+// kubeConfigNaming determines the names of the cluster, user and contexts in a generated kubeconfig.
+type kubeConfigNaming interface {
+	clusterName(server string) string
+	userName(username, cluster string) string
+	contextName(namespace, cluster, username string, current bool) string
+}
+
+// defaultNaming names the cluster after its server and the user after username. The current context is named
+// '<username>-context', and other contexts '<username>-<namespace>-context'.
+type defaultNaming struct{}
+
+func (defaultNaming) clusterName(server string) string {
+	return server
+}
+
+func (defaultNaming) userName(username, _ string) string {
+	return username
+}
+
+func (defaultNaming) contextName(namespace, _, username string, current bool) string {
+	if current {
+		return fmt.Sprintf("%s-context", username)
+	}
+	return fmt.Sprintf("%s-%s-context", username, namespace)
+}
+
+// generateKubeConfig returns a kubeconfig with a context named '<username>-context' for namespace, which is
+// current, and a context named '<username>-<namespace>-context' for each other namespace in additionalNamespaces.
+func generateKubeConfig(credentials User, cluster ClusterInfo, namespace, username string, additionalNamespaces ...string) *KubeConfig {
+	return generateNamedKubeConfig(defaultNaming{}, credentials, cluster, namespace, username, additionalNamespaces...)
+}
+
+// generateNamedKubeConfig returns a kubeconfig with a context for namespace, which is current, and for each other
+// namespace in additionalNamespaces, using names determined by naming.
+func generateNamedKubeConfig(naming kubeConfigNaming, credentials User, cluster ClusterInfo, namespace, username string, additionalNamespaces ...string) *KubeConfig {
+	clusterName := naming.clusterName(cluster.Server)
+	userName := naming.userName(username, clusterName)
+	currentContext := naming.contextName(namespace, clusterName, username, true)
+	contexts := []Contexts{
+		{
+			Context: Context{
+				Cluster:   clusterName,
+				Namespace: namespace,
+				User:      userName,
+			},
+			Name: currentContext,
+		},
+	}
+	seen := map[string]bool{namespace: true}
+	for _, additional := range additionalNamespaces {
+		if additional == "" || seen[additional] {
+			continue
+		}
+		seen[additional] = true
+		contexts = append(contexts, Contexts{
+			Context: Context{
+				Cluster:   clusterName,
+				Namespace: additional,
+				User:      userName,
+			},
+			Name: naming.contextName(additional, clusterName, username, false),
+		})
+	}
 	return &KubeConfig{
 		APIVersion: "v1",
 		Clusters: []Clusters{
 			{
-				Cluster: ClusterInfo{
-					CertificateAuthority: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
-					Server:               server,
-				},
-				Name: server,
+				Cluster: cluster,
+				Name:    clusterName,
 			},
 		},
 		Users: []Users{
 			{
-				Name: username,
-				User: User{
-					Token: token,
-				},
-			},
-		},
-		Contexts: []Contexts{
-			{
-				Context: Context{
-					Cluster:   server,
-					Namespace: namespace,
-					User:      username,
-				},
-				Name: currentContext,
+				Name: userName,
+				User: credentials,
 			},
 		},
+		Contexts:       contexts,
 		CurrentContext: currentContext,
 		Kind:           "Config",
 	}
@@ -14,19 +14,243 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
+var testCluster = ClusterInfo{
+	CertificateAuthority: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+	Server:               "https://0.0.0.0:9999",
+}
+
 func TestCreateKubeConfigText(t *testing.T) {
 	token, namespace, username := "test-token", "test-namespace", "test-username"
 	testHost, testPort := "0.0.0.0", "9999"
 	t.Setenv("KUBERNETES_SERVICE_HOST", testHost)
 	t.Setenv("KUBERNETES_SERVICE_PORT", testPort)
-	result, err := CreateKubeConfigText(token, namespace, username)
+	result, err := CreateKubeConfigText(User{Token: token}, config.ClusterOverrides{}, namespace, username)
 	assert.NoError(t, err)
 	assert.Contains(t, result, "token: "+token)
 	assert.Contains(t, result, "namespace: "+namespace)
 	assert.Contains(t, result, "user: "+username)
 	hostAndPort := fmt.Sprintf("https://%s:%s", testHost, testPort)
 	assert.Contains(t, result, "cluster: "+hostAndPort)
+
+	execConfig := &ExecConfig{
+		APIVersion:      "client.authentication.k8s.io/v1",
+		Command:         "/bin/sh",
+		Args:            []string{"-c", "echo"},
+		Env:             []ExecEnvVar{{Name: "TEST", Value: "test"}},
+		InteractiveMode: "Never",
+	}
+	result, err = CreateKubeConfigText(User{Exec: execConfig}, config.ClusterOverrides{}, namespace, username)
+	assert.NoError(t, err)
+	assert.NotContains(t, result, "token:", "Should not write token when using exec credential plugin")
+	var kubeconfig KubeConfig
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &kubeconfig)) && assert.Len(t, kubeconfig.Users, 1) {
+		assert.Equal(t, execConfig, kubeconfig.Users[0].User.Exec)
+	}
+}
+
+func TestCreateKubeConfigTextWithClusterOverrides(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "9999")
+	overrides := config.ClusterOverrides{
+		Server:                   "https://api.example.com:6443",
+		CertificateAuthorityData: "dGVzdA==",
+		TLSServerName:            "api.example.com",
+		ProxyURL:                 "http://proxy:3128",
+	}
+	result, err := CreateKubeConfigText(User{Token: "test-token"}, overrides, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var kubeconfig KubeConfig
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &kubeconfig)) {
+		return
+	}
+	assert.Equal(t, []Clusters{{
+		Name: "https://api.example.com:6443",
+		Cluster: ClusterInfo{
+			Server:                   "https://api.example.com:6443",
+			CertificateAuthorityData: "dGVzdA==",
+			TLSServerName:            "api.example.com",
+			ProxyURL:                 "http://proxy:3128",
+		},
+	}}, kubeconfig.Clusters)
+	assert.NotContains(t, result, "certificate-authority:", "Should not reference service account CA if CA data is provided")
+	assert.Equal(t, "https://api.example.com:6443", kubeconfig.Contexts[0].Context.Cluster)
+
+	result, err = CreateKubeConfigText(User{Token: "test-token"}, config.ClusterOverrides{TLSServerName: "kubernetes.default.svc"}, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	kubeconfig = KubeConfig{}
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &kubeconfig)) {
+		expected := testCluster
+		expected.TLSServerName = "kubernetes.default.svc"
+		assert.Equal(t, []Clusters{{Name: testCluster.Server, Cluster: expected}}, kubeconfig.Clusters, "Should use in-cluster defaults for fields that are not overridden")
+	}
+	assert.NotContains(t, result, "proxy-url", "Should omit empty fields")
+}
+
+func TestCreateKubeConfigTextWithAdditionalNamespaces(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "9999")
+	result, err := CreateKubeConfigText(User{Token: "test-token"}, config.ClusterOverrides{}, "ns-b", "test-username", "ns-a", "ns-b", "", "ns-c", "ns-a")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var kubeconfig KubeConfig
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &kubeconfig)) {
+		return
+	}
+	assert.Equal(t, "test-username-context", kubeconfig.CurrentContext, "Should use context for namespace as current context")
+	assert.Equal(t, []Contexts{
+		{Name: "test-username-context", Context: Context{Cluster: testCluster.Server, Namespace: "ns-b", User: "test-username"}},
+		{Name: "test-username-ns-a-context", Context: Context{Cluster: testCluster.Server, Namespace: "ns-a", User: "test-username"}},
+		{Name: "test-username-ns-c-context", Context: Context{Cluster: testCluster.Server, Namespace: "ns-c", User: "test-username"}},
+	}, kubeconfig.Contexts, "Should add one context per namespace, ignoring duplicates and empty namespaces")
+	assert.Len(t, kubeconfig.Users, 1)
+	assert.Len(t, kubeconfig.Clusters, 1)
+}
+
+func TestUpdateKubeConfigToken(t *testing.T) {
+	original, err := yaml.Marshal(generateKubeConfig(User{Token: "old-token"}, testCluster, "test-namespace", "test-username"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result, err := UpdateKubeConfigToken(string(original), "test-username", "new-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var updated KubeConfig
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &updated)) {
+		return
+	}
+	expected := generateKubeConfig(User{Token: "new-token"}, testCluster, "test-namespace", "test-username")
+	assert.Equal(t, *expected, updated, "Should only update token")
+
+	result, err = UpdateKubeConfigToken(string(original), "other-username", "new-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	updated = KubeConfig{}
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &updated)) {
+		return
+	}
+	assert.Equal(t, []Users{
+		{Name: "test-username", User: User{Token: "old-token"}},
+		{Name: "other-username", User: User{Token: "new-token"}},
+	}, updated.Users, "Should add user if not present")
+
+	_, err = UpdateKubeConfigToken("", "test-username", "new-token")
+	assert.Error(t, err)
+	_, err = UpdateKubeConfigToken("users: invalid", "test-username", "new-token")
+	assert.Error(t, err)
+}
+
+func TestRemoveKubeConfigCredentials(t *testing.T) {
+	original, err := yaml.Marshal(generateKubeConfig(User{Token: "test-token"}, testCluster, "test-namespace", "test-username"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	result, err := RemoveKubeConfigCredentials(string(original), "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, result, "test-token")
+	var removed KubeConfig
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &removed)) {
+		expected := generateKubeConfig(User{}, testCluster, "test-namespace", "test-username")
+		assert.Equal(t, *expected, removed, "Should only remove user's credentials")
+	}
+
+	result, err = RemoveKubeConfigCredentials(string(original), "other-username")
+	if assert.NoError(t, err) {
+		assert.Contains(t, result, "token: test-token", "Should not remove credentials of other users")
+	}
+	_, err = RemoveKubeConfigCredentials("users: invalid", "test-username")
+	assert.Error(t, err)
+}
+
+func TestMergeKubeConfig(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "9999")
+	server := "https://0.0.0.0:9999"
+	existing := `apiVersion: v1
+kind: Config
+preferences:
+  colors: true
+clusters:
+- name: other-cluster
+  cluster:
+    server: https://other:6443
+- name: ` + server + `
+  cluster:
+    server: https://stale:6443
+users:
+- name: other-user
+  user:
+    token: other-token
+- name: test-username
+  user:
+    token: old-token
+contexts:
+- name: other-context
+  context:
+    cluster: other-cluster
+    user: other-user
+current-context: other-context
+`
+	result, err := MergeKubeConfig(existing, User{Token: "test-token"}, config.ClusterOverrides{}, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var merged KubeConfig
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &merged)) {
+		return
+	}
+	assert.Equal(t, "test-username-context", merged.CurrentContext)
+	assert.Equal(t, []Clusters{
+		{Name: "other-cluster", Cluster: ClusterInfo{Server: "https://other:6443"}},
+		{Name: server, Cluster: ClusterInfo{Server: server, CertificateAuthority: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"}},
+	}, merged.Clusters, "Should replace cluster with same name and preserve others")
+	assert.Equal(t, []Users{
+		{Name: "other-user", User: User{Token: "other-token"}},
+		{Name: "test-username", User: User{Token: "test-token"}},
+	}, merged.Users, "Should replace user with same name and preserve others")
+	assert.Equal(t, []Contexts{
+		{Name: "other-context", Context: Context{Cluster: "other-cluster", User: "other-user"}},
+		{Name: "test-username-context", Context: Context{Cluster: server, Namespace: "test-namespace", User: "test-username"}},
+	}, merged.Contexts, "Should add context and preserve others")
+	assert.Contains(t, result, "colors: true", "Should preserve unknown fields")
+
+	result, err = MergeKubeConfig(existing, User{Token: "test-token"}, config.ClusterOverrides{}, "test-namespace", "test-username", "other-namespace")
+	if !assert.NoError(t, err) {
+		return
+	}
+	merged = KubeConfig{}
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &merged)) {
+		assert.Equal(t, "test-username-context", merged.CurrentContext)
+		assert.Equal(t, []Contexts{
+			{Name: "other-context", Context: Context{Cluster: "other-cluster", User: "other-user"}},
+			{Name: "test-username-context", Context: Context{Cluster: server, Namespace: "test-namespace", User: "test-username"}},
+			{Name: "test-username-other-namespace-context", Context: Context{Cluster: server, Namespace: "other-namespace", User: "test-username"}},
+		}, merged.Contexts, "Should add contexts for additional namespaces")
+	}
+
+	result, err = MergeKubeConfig("", User{Token: "test-token"}, config.ClusterOverrides{}, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	merged = KubeConfig{}
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &merged)) {
+		assert.Equal(t, *generateKubeConfig(User{Token: "test-token"}, testCluster, "test-namespace", "test-username"), merged, "Should create kubeconfig if none exists")
+	}
+
+	_, err = MergeKubeConfig("contexts: invalid", User{Token: "test-token"}, config.ClusterOverrides{}, "test-namespace", "test-username")
+	assert.Error(t, err, "Should not overwrite invalid kubeconfig")
 }
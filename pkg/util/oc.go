@@ -0,0 +1,76 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package util
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+)
+
+// MergeOcKubeConfig adds a cluster, user and contexts for credentials, namespace, username and additionalNamespaces
+// to the existing kubeconfig, named as `oc login` names them, and makes the context for namespace current. Entries
+// with the same names are replaced, so that logging in to the same cluster with oc reuses the entries rather than
+// adding new ones. Other clusters, users and contexts in existing are preserved.
+func MergeOcKubeConfig(existing string, credentials User, overrides config.ClusterOverrides, namespace, username string, additionalNamespaces ...string) (string, error) {
+	cluster, err := clusterInfo(overrides)
+	if err != nil {
+		return "", err
+	}
+	return mergeKubeConfig(existing, generateNamedKubeConfig(ocNaming{}, credentials, cluster, namespace, username, additionalNamespaces...))
+}
+
+// RemoveOcKubeConfigCredentials removes the credentials of the user added by MergeOcKubeConfig for username and
+// overrides from kubeconfig, leaving an empty user.
+func RemoveOcKubeConfigCredentials(kubeconfig string, overrides config.ClusterOverrides, username string) (string, error) {
+	cluster, err := clusterInfo(overrides)
+	if err != nil {
+		return "", err
+	}
+	naming := ocNaming{}
+	return RemoveKubeConfigCredentials(kubeconfig, naming.userName(username, naming.clusterName(cluster.Server)))
+}
+
+// UpdateOcKubeConfigToken replaces the credentials of the user added by MergeOcKubeConfig for username and
+// overrides in kubeconfig with token.
+func UpdateOcKubeConfigToken(kubeconfig string, overrides config.ClusterOverrides, username, token string) (string, error) {
+	cluster, err := clusterInfo(overrides)
+	if err != nil {
+		return "", err
+	}
+	naming := ocNaming{}
+	return UpdateKubeConfigToken(kubeconfig, naming.userName(username, naming.clusterName(cluster.Server)), token)
+}
+
+// ocNaming names the cluster '<host>-<port>' after its server, with dots in the host replaced by dashes, the user
+// '<username>/<cluster>' and contexts '<namespace>/<cluster>/<username>', matching the kubeconfigs written by
+// `oc login`.
+type ocNaming struct{}
+
+func (ocNaming) clusterName(server string) string {
+	host := server
+	if parsed, err := url.Parse(server); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return strings.ReplaceAll(host, ".", "-")
+}
+
+func (ocNaming) userName(username, cluster string) string {
+	return username + "/" + cluster
+}
+
+func (ocNaming) contextName(namespace, cluster, username string, _ bool) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return namespace + "/" + cluster + "/" + username
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2019-2025 Red Hat, Inc.
+// This program and the accompanying materials are made
+// available under the terms of the Eclipse Public License 2.0
+// which is available at https://www.eclipse.org/legal/epl-2.0/
+//
+// SPDX-License-Identifier: EPL-2.0
+//
+// Contributors:
+//   Red Hat, Inc. - initial API and implementation
+
+package util
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/web-terminal-exec/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMergeOcKubeConfig(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "0.0.0.0")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "9999")
+	overrides := config.ClusterOverrides{Server: "https://api.example.com:6443"}
+	existing := `apiVersion: v1
+kind: Config
+clusters:
+- name: api-example-com:6443
+  cluster:
+    server: https://api.example.com:6443
+users:
+- name: test-username/api-example-com:6443
+  user:
+    token: old-token
+contexts:
+- name: other-namespace/api-example-com:6443/test-username
+  context:
+    cluster: api-example-com:6443
+    namespace: other-namespace
+    user: test-username/api-example-com:6443
+current-context: other-namespace/api-example-com:6443/test-username
+`
+	result, err := MergeOcKubeConfig(existing, User{Token: "test-token"}, overrides, "test-namespace", "test-username", "other-namespace")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var merged KubeConfig
+	if !assert.NoError(t, yaml.Unmarshal([]byte(result), &merged)) {
+		return
+	}
+	assert.Equal(t, "test-namespace/api-example-com:6443/test-username", merged.CurrentContext)
+	assert.Equal(t, []Clusters{
+		{Name: "api-example-com:6443", Cluster: ClusterInfo{Server: "https://api.example.com:6443", CertificateAuthority: serviceAccountCAFile}},
+	}, merged.Clusters, "Should replace cluster added by oc login")
+	assert.Equal(t, []Users{
+		{Name: "test-username/api-example-com:6443", User: User{Token: "test-token"}},
+	}, merged.Users, "Should replace user added by oc login")
+	assert.Equal(t, []Contexts{
+		{Name: "other-namespace/api-example-com:6443/test-username", Context: Context{Cluster: "api-example-com:6443", Namespace: "other-namespace", User: "test-username/api-example-com:6443"}},
+		{Name: "test-namespace/api-example-com:6443/test-username", Context: Context{Cluster: "api-example-com:6443", Namespace: "test-namespace", User: "test-username/api-example-com:6443"}},
+	}, merged.Contexts, "Should name contexts as oc login does")
+
+	result, err = MergeOcKubeConfig("", User{Token: "test-token"}, config.ClusterOverrides{}, "", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	merged = KubeConfig{}
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &merged)) {
+		assert.Equal(t, "default/0-0-0-0:9999/test-username", merged.CurrentContext, "Should use in-cluster server and name context for default namespace")
+	}
+}
+
+func TestRemoveOcKubeConfigCredentials(t *testing.T) {
+	overrides := config.ClusterOverrides{Server: "https://api.example.com:6443"}
+	kubeconfig, err := MergeOcKubeConfig("", User{Token: "test-token"}, overrides, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	result, err := RemoveOcKubeConfigCredentials(kubeconfig, overrides, "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var removed KubeConfig
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &removed)) {
+		assert.Equal(t, []Users{{Name: "test-username/api-example-com:6443"}}, removed.Users)
+		assert.Equal(t, "test-namespace/api-example-com:6443/test-username", removed.CurrentContext)
+	}
+}
+
+func TestUpdateOcKubeConfigToken(t *testing.T) {
+	overrides := config.ClusterOverrides{Server: "https://api.example.com:6443"}
+	kubeconfig, err := MergeOcKubeConfig("", User{Token: "old-token"}, overrides, "test-namespace", "test-username")
+	if !assert.NoError(t, err) {
+		return
+	}
+	result, err := UpdateOcKubeConfigToken(kubeconfig, overrides, "test-username", "new-token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var updated KubeConfig
+	if assert.NoError(t, yaml.Unmarshal([]byte(result), &updated)) {
+		assert.Equal(t, []Users{{Name: "test-username/api-example-com:6443", User: User{Token: "new-token"}}}, updated.Users)
+		assert.Equal(t, "test-namespace/api-example-com:6443/test-username", updated.CurrentContext)
+	}
+}
@@ -11,13 +11,14 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/errors"
+	"github.com/redhat-developer/web-terminal-exec/pkg/logging"
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
-	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -28,49 +29,50 @@ const (
 	catEtcPasswdCommand = "cat /etc/passwd"
 )
 
-func DetectShell(client kubernetes.Interface, restconfig *rest.Config, podName, containerName string) (string, error) {
+func DetectShell(ctx context.Context, client kubernetes.Interface, restconfig *rest.Config, podName, containerName string) (string, error) {
+	log := logging.FromContext(ctx)
 	// Try to get shell from $SHELL env var
-	stdout, stderr, err := operations.ExecCommandInPod(client, restconfig, podName, containerName, getShellCommand)
+	stdout, stderr, err := operations.ExecCommandInPod(ctx, client, restconfig, podName, containerName, getShellCommand)
 	if err == nil {
 		shellEnv := strings.TrimSuffix(stdout.String(), "\n")
-		logrus.Debugf("Detected shell '%s' from $SHELL environment variable", shellEnv)
+		log.Debugf("Detected shell '%s' from $SHELL environment variable", shellEnv)
 		if shellEnv != "" {
 			return shellEnv, nil
 		}
 	} else {
-		logrus.Infof("Failed to read $SHELL environment variable in container %s in pod %s: %s", containerName, podName, err)
-		logrus.Debugf("Command stdout: %s", stdout.String())
-		logrus.Debugf("Command stderr: %s", stderr.String())
+		log.Infof("Failed to read $SHELL environment variable in container %s in pod %s: %s", containerName, podName, err)
+		log.Debugf("Command stdout: %s", stdout.String())
+		log.Debugf("Command stderr: %s", stderr.String())
 	}
 
 	// Try to read shell from /etc/passwd directly
-	stdout, stderr, err = operations.ExecCommandInPod(client, restconfig, podName, containerName, getUserIdCommand)
+	stdout, stderr, err = operations.ExecCommandInPod(ctx, client, restconfig, podName, containerName, getUserIdCommand)
 	if err != nil {
-		logrus.Errorf("Failed to get user ID in container %s in pod %s: %s", containerName, podName, err)
-		logrus.Debugf("Command stdout: %s", stdout.String())
-		logrus.Debugf("Command stderr: %s", stderr.String())
+		log.Errorf("Failed to get user ID in container %s in pod %s: %s", containerName, podName, err)
+		log.Debugf("Command stdout: %s", stdout.String())
+		log.Debugf("Command stderr: %s", stderr.String())
 		return "", errors.NewInternalErrorf("failed to get user ID in container %s in pod %s", containerName, podName)
 	}
 	userID := strings.TrimSuffix(stdout.String(), "\n")
-	logrus.Debugf("Detected user ID: '%s'", userID)
+	log.Debugf("Detected user ID: '%s'", userID)
 
-	stdout, stderr, err = operations.ExecCommandInPod(client, restconfig, podName, containerName, catEtcPasswdCommand)
+	stdout, stderr, err = operations.ExecCommandInPod(ctx, client, restconfig, podName, containerName, catEtcPasswdCommand)
 	if err != nil {
-		logrus.Errorf("Failed to read /etc/passwd in container %s in pod %s: %s", containerName, podName, err)
-		logrus.Debugf("Command stdout: %s", stdout.String())
-		logrus.Debugf("Command stderr: %s", stderr.String())
+		log.Errorf("Failed to read /etc/passwd in container %s in pod %s: %s", containerName, podName, err)
+		log.Debugf("Command stdout: %s", stdout.String())
+		log.Debugf("Command stderr: %s", stderr.String())
 		return "", errors.NewInternalErrorf("failed to read /etc/passwd in container %s in pod %s", containerName, podName)
 	}
 
 	etcPasswd := stdout.String()
-	logrus.Debugf("Content of /etc/passwd: '%s'", etcPasswd)
+	log.Debugf("Content of /etc/passwd: '%s'", etcPasswd)
 
 	shell, err := parseShellFromEtcPasswd(etcPasswd, userID)
 	if err != nil {
-		logrus.Errorf("Error parsing /etc/passwd: %s", err)
+		log.Errorf("Error parsing /etc/passwd: %s", err)
 		return "", errors.NewInternalErrorf("failed to parse shell from /etc/passwd in container %s", containerName)
 	}
-	logrus.Debugf("Detected shell %s from /etc/passwd", shell)
+	log.Debugf("Detected shell %s from /etc/passwd", shell)
 
 	return shell, nil
 }
@@ -11,6 +11,7 @@
 package util
 
 import (
+	"context"
 	"testing"
 
 	"github.com/redhat-developer/web-terminal-exec/pkg/operations"
@@ -127,7 +128,7 @@ func TestDetectShell(t *testing.T) {
 			operations.NewSPDYExecutor = fakeSPDY.NewFakeSPDYExecutor
 			defer func() { operations.NewSPDYExecutor = oldSPDYExecutor }()
 
-			result, err := DetectShell(fakeClient, &rest.Config{}, testPodName, testContainerName)
+			result, err := DetectShell(context.Background(), fakeClient, &rest.Config{}, testPodName, testContainerName)
 			if tt.errRegexp != "" {
 				assert.Error(t, err)
 				assert.Regexp(t, tt.errRegexp, err.Error())